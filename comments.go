@@ -0,0 +1,276 @@
+package ini
+
+import (
+	"strings"
+	"unicode"
+)
+
+var defaultCommentPrefixes = []string{";", "#"}
+
+func (f *file) commentPrefixes() []string {
+	if len(f.parseOptions.CommentPrefixes) > 0 {
+		return f.parseOptions.CommentPrefixes
+	}
+	if f.parseOptions.Dialect == DialectDesktop {
+		return desktopCommentPrefixes
+	}
+	if f.parseOptions.Dialect == DialectPHP {
+		return phpCommentPrefixes
+	}
+	return defaultCommentPrefixes
+}
+
+// A marker such as REM that ends in a letter or digit is only a marker when it is a whole word
+func isWordMarker(marker string) bool {
+	last := rune(marker[len(marker)-1])
+	return unicode.IsLetter(last) || unicode.IsDigit(last)
+}
+
+// Returns the comment marker that some text starts with, or an empty string if it does not start with one
+func (f *file) commentPrefix(text string) string {
+	for _, marker := range f.commentPrefixes() {
+		if marker == "" || !strings.HasPrefix(text, marker) {
+			continue
+		}
+		if rest := text[len(marker):]; isWordMarker(marker) && rest != "" && !unicode.IsSpace(rune(rest[0])) {
+			continue
+		}
+		return marker
+	}
+	return ""
+}
+
+// Returns the position of an inline comment in a value, or -1 if there is none.
+// A value that starts with a quote may contain comment markers up to its closing quote.
+func (f *file) inlineCommentStart(value string) int {
+	pos := 0
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		pos = -1
+		for i := 1; i < len(value); i++ {
+			if value[0] == '"' && value[i] == '\\' {
+				i++
+			} else if value[i] == value[0] {
+				pos = i + 1
+				break
+			}
+		}
+		if pos < 0 {
+			// Without a closing quote there is nothing to tell the value from a comment
+			return -1
+		}
+	}
+	for i := pos; i < len(value); i++ {
+		afterSpace := i == 0 || unicode.IsSpace(rune(value[i-1]))
+		if f.parseOptions.InlineCommentSpace && !afterSpace {
+			continue
+		}
+		if marker := f.commentPrefix(value[i:]); marker != "" && (afterSpace || !isWordMarker(marker)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// The text of a comment without its indentation, marker and the space following the marker
+func (f *file) commentText(raw string) string {
+	text := strings.TrimSpace(raw)
+	if marker := f.commentPrefix(text); marker != "" {
+		text = strings.TrimPrefix(text[len(marker):], " ")
+	}
+	return text
+}
+
+func (f *file) joinComments(lines []*line) string {
+	text := make([]string, len(lines))
+	for i, l := range lines {
+		text[i] = f.commentText(l.prefix)
+	}
+	return strings.Join(text, "\n")
+}
+
+// The marker to write new comments with where there is no existing comment to follow
+func (f *file) defaultCommentMarker() string {
+	if f.writeOptions.CommentPrefix != "" {
+		return f.writeOptions.CommentPrefix
+	}
+	for _, marker := range f.commentPrefixes() {
+		if marker == "#" {
+			return marker
+		}
+	}
+	return f.commentPrefixes()[0]
+}
+
+// The marker to write new comments with, following the existing comments where there are any
+func (f *file) commentMarker(existing []*line) string {
+	for _, l := range existing {
+		if marker := f.commentPrefix(strings.TrimSpace(l.prefix)); marker != "" {
+			return marker
+		}
+	}
+	return f.defaultCommentMarker()
+}
+
+// Build the comment lines for a comment, indented to match the line they describe
+func (f *file) commentLines(comment, indent string, existing []*line) (lines []*line) {
+	if comment == "" {
+		return nil
+	}
+	marker := f.commentMarker(existing)
+	for _, text := range strings.Split(comment, "\n") {
+		if text != "" {
+			text = " " + text
+		}
+		lines = append(lines, &line{kind: commentLine, prefix: indent + marker + text, eol: f.lineEnding()})
+	}
+	return
+}
+
+func indentOf(l *line) string {
+	return l.prefix[:len(l.prefix)-len(strings.TrimLeftFunc(l.prefix, unicode.IsSpace))]
+}
+
+// The text of the comment following a section header or value on the same line
+func (f *file) inlineComment(l *line) string {
+	return f.commentText(l.suffix)
+}
+
+func (f *file) setInlineComment(l *line, comment string) {
+	padding := " "
+	if trimmed := strings.TrimLeftFunc(l.suffix, unicode.IsSpace); trimmed != "" {
+		// Keep any alignment that the existing comment had
+		padding = l.suffix[:len(l.suffix)-len(trimmed)]
+	}
+	if comment == "" {
+		l.suffix = ""
+		return
+	}
+	marker := f.commentPrefix(strings.TrimSpace(l.suffix))
+	if marker == "" {
+		marker = f.defaultCommentMarker()
+	}
+	l.suffix = padding + marker + " " + strings.Replace(comment, "\n", " ", -1)
+}
+
+// The leading comment lines at the top of the file that are followed by a blank line, or by nothing at all
+func (f *file) headerComment() (lines []*line) {
+	def := f.defaultBlock()
+	end := 0
+	for end < len(def.lines) && def.lines[end].kind == commentLine {
+		end++
+	}
+	if end == 0 {
+		return nil
+	}
+	if end < len(def.lines) && def.lines[end].kind != blankLine {
+		return nil
+	}
+	if end == len(def.lines) && len(f.blocks) > 1 {
+		return nil
+	}
+	return def.lines[:end]
+}
+
+func (f *file) HeaderComment() string {
+	return f.joinComments(f.headerComment())
+}
+
+func (f *file) SetHeaderComment(comment string) {
+	def := f.defaultBlock()
+	existing := f.headerComment()
+	rest := def.lines[len(existing):]
+	if len(existing) > 0 && len(rest) > 0 && comment == "" {
+		// Drop the blank line that separated the old header
+		rest = rest[1:]
+	}
+	lines := f.commentLines(comment, "", existing)
+	if len(existing) == 0 && len(lines) > 0 {
+		lines = append(lines, &line{kind: blankLine, eol: f.lineEnding()})
+	}
+	def.lines = append(lines, rest...)
+}
+
+// Returns the header of the first block for a section, creating the section if it does not exist
+func (f *file) sectionHeader(section string) *line {
+	for _, b := range f.blocks {
+		if b.header != nil && b.name == f.norm(section) {
+			return b.header
+		}
+	}
+	f.section(section)
+	return f.lastBlock(section).header
+}
+
+// The line that comments for a key are attached to: the string value if there is one, or else the first array value
+func (f *file) commentedLine(section, key string) *line {
+	if lines := f.keyLines(section, key, valueLine); len(lines) > 0 {
+		return f.activeLine(lines)
+	}
+	if lines := f.keyLines(section, key, arrayLine); len(lines) > 0 {
+		return lines[0]
+	}
+	return nil
+}
+
+func (f *file) SectionComment(section string) string {
+	if section == "" || f.sections[f.norm(section)] == nil {
+		return ""
+	}
+	return f.joinComments(f.sectionHeader(section).comments)
+}
+
+func (f *file) SetSectionComment(section, comment string) (ok bool) {
+	if section == "" {
+		return false
+	}
+	header := f.sectionHeader(section)
+	header.comments = f.commentLines(comment, indentOf(header), header.comments)
+	return true
+}
+
+func (f *file) SectionInlineComment(section string) string {
+	if section == "" || f.sections[f.norm(section)] == nil {
+		return ""
+	}
+	return f.inlineComment(f.sectionHeader(section))
+}
+
+func (f *file) SetSectionInlineComment(section, comment string) (ok bool) {
+	if section == "" {
+		return false
+	}
+	f.setInlineComment(f.sectionHeader(section), comment)
+	return true
+}
+
+func (f *file) KeyComment(section, key string) string {
+	if l := f.commentedLine(section, key); l != nil {
+		return f.joinComments(l.comments)
+	}
+	return ""
+}
+
+func (f *file) SetKeyComment(section, key, comment string) (ok bool) {
+	l := f.commentedLine(section, key)
+	if l == nil {
+		return false
+	}
+	l.comments = f.commentLines(comment, indentOf(l), l.comments)
+	return true
+}
+
+func (f *file) KeyInlineComment(section, key string) string {
+	if l := f.commentedLine(section, key); l != nil {
+		return f.inlineComment(l)
+	}
+	return ""
+}
+
+func (f *file) SetKeyInlineComment(section, key, comment string) (ok bool) {
+	l := f.commentedLine(section, key)
+	if l == nil {
+		return false
+	}
+	f.setInlineComment(l, comment)
+	return true
+}
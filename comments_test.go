@@ -0,0 +1,198 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadComments(t *testing.T) {
+	src := `# Generated file
+# Do not edit
+
+; Global settings
+global = 1
+
+# The web server
+[web] ; inline
+  # Port to listen on
+  # (privileged below 1024)
+  port = 80
+list[] = a
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(name, got, expect string) {
+		if got != expect {
+			t.Errorf("%s: expected %q, got %q", name, expect, got)
+		}
+	}
+	check("HeaderComment", file.HeaderComment(), "Generated file\nDo not edit")
+	check("KeyComment(global)", file.KeyComment("", "global"), "Global settings")
+	check("SectionComment(web)", file.SectionComment("web"), "The web server")
+	check("SectionInlineComment(web)", file.SectionInlineComment("web"), "inline")
+	check("KeyComment(port)", file.KeyComment("web", "port"), "Port to listen on\n(privileged below 1024)")
+	check("KeyComment(list)", file.KeyComment("web", "list"), "")
+	check("SectionComment(missing)", file.SectionComment("missing"), "")
+	checkStr(t, file, "web", "port", "80")
+}
+
+func TestWriteComments(t *testing.T) {
+	file := NewFile()
+	file.SetHeaderComment("Provisioned config")
+	file.Set("db", "host", "localhost")
+	file.SetArr("db", "replicas", []string{"a", "b"})
+	if !file.SetSectionComment("db", "Database connection") {
+		t.Error("SetSectionComment: expected to set a comment")
+	}
+	if file.SetSectionComment("", "nothing") {
+		t.Error("SetSectionComment: expected the default section to be rejected")
+	}
+	file.SetSectionInlineComment("db", "primary")
+	file.SetKeyComment("db", "host", "Hostname\nor IP address")
+	file.SetKeyComment("db", "replicas", "Read only")
+	file.SetKeyInlineComment("db", "host", "no port")
+	if file.SetKeyComment("db", "missing", "comment") {
+		t.Error("SetKeyComment: expected a missing key to be rejected")
+	}
+
+	expected := `# Provisioned config
+
+# Database connection
+[db] # primary
+# Hostname
+# or IP address
+host = localhost # no port
+# Read only
+replicas[] = a
+replicas[] = b
+
+`
+	if out := writeString(t, file); out != expected {
+		t.Errorf("Incorrect output with comments; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+
+	file.SetHeaderComment("")
+	file.SetKeyComment("db", "host", "")
+	file.SetWriteOptions(WriteOptions{CommentPrefix: ";"})
+	file.SetSectionComment("db", "Database")
+	file.SetKeyComment("db", "replicas", "")
+	expected = `# Database
+[db] # primary
+host = localhost # no port
+replicas[] = a
+replicas[] = b
+
+`
+	if out := writeString(t, file); out != expected {
+		t.Errorf("Incorrect output after changing comments; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+}
+
+func TestCommentPrefix(t *testing.T) {
+	file := NewFile()
+	file.SetWriteOptions(WriteOptions{CommentPrefix: ";"})
+	file.Set("a", "b", "c")
+	file.SetSectionComment("a", "Section")
+	expected := "; Section\n[a]\nb = c\n\n"
+	if out := writeString(t, file); out != expected {
+		t.Errorf("Incorrect output with comment prefix; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+}
+
+func TestInlineComments(t *testing.T) {
+	src := `[server]
+port = 8080 ; default
+name = "web;01" # quoted
+path = '/a#b'
+url = http://example.com/#fragment
+empty = ; nothing
+continued = a \
+  b ; note
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{InlineComments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInt(t, file, "server", "port", 8080)
+	checkStr(t, file, "server", "name", "web;01")
+	checkStr(t, file, "server", "path", "/a#b")
+	checkStr(t, file, "server", "url", "http://example.com/")
+	checkStr(t, file, "server", "empty", "")
+	checkStr(t, file, "server", "continued", "a b")
+	if comment := file.KeyInlineComment("server", "port"); comment != "default" {
+		t.Errorf("KeyInlineComment(port): expected %q, got %q", "default", comment)
+	}
+	if comment := file.KeyInlineComment("server", "continued"); comment != "note" {
+		t.Errorf("KeyInlineComment(continued): expected %q, got %q", "note", comment)
+	}
+	if out := writeString(t, file); out != src {
+		t.Errorf("Round trip changed the file; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	file.SetInt("server", "port", 9090)
+	file.Set("server", "new", "a;b")
+	if !strings.Contains(writeString(t, file), "port = 9090 ; default\n") {
+		t.Error("expected Set to keep the inline comment")
+	}
+	if !strings.Contains(writeString(t, file), `new = "a;b"`) {
+		t.Error("expected a value containing a comment marker to be quoted")
+	}
+
+	t.Run("needSpace", func(t *testing.T) {
+		file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{InlineComments: true, InlineCommentSpace: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkStr(t, file, "server", "url", "http://example.com/#fragment")
+		checkInt(t, file, "server", "port", 8080)
+		file.Set("server", "empty", "x")
+		if out := writeString(t, file); !strings.Contains(out, "empty = x ; nothing\n") {
+			t.Errorf("expected space before the inline comment; got: <<<%s<<<", out)
+		}
+		reread, err := LoadWithOptions(strings.NewReader(writeString(t, file)), ParseOptions{InlineComments: true, InlineCommentSpace: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkStr(t, reread, "server", "empty", "x")
+	})
+	t.Run("disabled", func(t *testing.T) {
+		file, err := Load(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkStr(t, file, "server", "port", "8080 ; default")
+	})
+}
+
+func TestCommentPrefixes(t *testing.T) {
+	src := `// Header
+
+REM about the section
+[section] // inline
+REMOTE = host
+#key = value
+value = 1 // one
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{CommentPrefixes: []string{"//", "REM"}, InlineComments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "section", "REMOTE", "host")
+	checkStr(t, file, "section", "#key", "value")
+	checkStr(t, file, "section", "value", "1")
+	if comment := file.HeaderComment(); comment != "Header" {
+		t.Errorf("HeaderComment: expected %q, got %q", "Header", comment)
+	}
+	if comment := file.SectionComment("section"); comment != "about the section" {
+		t.Errorf("SectionComment: expected %q, got %q", "about the section", comment)
+	}
+	if comment := file.SectionInlineComment("section"); comment != "inline" {
+		t.Errorf("SectionInlineComment: expected %q, got %q", "inline", comment)
+	}
+	file.SetKeyComment("section", "REMOTE", "Remote host")
+	if !strings.Contains(writeString(t, file), "// Remote host\nREMOTE = host\n") {
+		t.Errorf("expected a new comment to use a configured marker, got <<<%s<<<", writeString(t, file))
+	}
+}
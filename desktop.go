@@ -0,0 +1,136 @@
+package ini
+
+import "strings"
+
+// Comments in desktop entry files start with # only, so that ; can start a value
+var desktopCommentPrefixes = []string{"#"}
+
+// Interpret the escape sequences of a desktop entry value: \s, \n, \t, \r and \\.
+// Any other backslash, such as in the \; of a list, is kept as it is.
+func unescapeDesktop(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var ret strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			ret.WriteByte(value[i])
+			continue
+		}
+		switch value[i+1] {
+		case 's':
+			ret.WriteByte(' ')
+		case 'n':
+			ret.WriteByte('\n')
+		case 't':
+			ret.WriteByte('\t')
+		case 'r':
+			ret.WriteByte('\r')
+		case '\\':
+			ret.WriteByte('\\')
+		default:
+			ret.WriteString(value[i : i+2])
+		}
+		i++
+	}
+	return ret.String()
+}
+
+// Escape a value for a desktop entry file, so that it is read back the same by unescapeDesktop
+func escapeDesktop(value string) string {
+	escaped := strings.NewReplacer("\\", `\\`, "\n", `\n`, "\t", `\t`, "\r", `\r`).Replace(value)
+	if strings.HasPrefix(escaped, " ") {
+		// Leading whitespace would be taken as part of the layout
+		escaped = `\s` + escaped[1:]
+	}
+	if strings.HasSuffix(escaped, " ") {
+		escaped = escaped[:len(escaped)-1] + `\s`
+	}
+	return escaped
+}
+
+// Split a desktop entry list, such as `Network;WebBrowser;`, at the semicolons that are not escaped as \;
+func desktopList(raw string) []string {
+	values := []string{}
+	var current strings.Builder
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\' && i+1 < len(raw) && raw[i+1] == ';':
+			current.WriteByte(';')
+			i++
+		case raw[i] == '\\' && i+1 < len(raw):
+			// Keep other escapes whole, so that \\; is a backslash at the end of a value
+			current.WriteString(raw[i : i+2])
+			i++
+		case raw[i] == ';':
+			values = append(values, unescapeDesktop(current.String()))
+			current.Reset()
+		default:
+			current.WriteByte(raw[i])
+		}
+	}
+	if current.Len() > 0 {
+		// The trailing semicolon is optional
+		values = append(values, unescapeDesktop(current.String()))
+	}
+	return values
+}
+
+// Join values into a desktop entry list, with a semicolon after each
+func joinDesktopList(values []string) string {
+	var joined strings.Builder
+	for _, value := range values {
+		joined.WriteString(strings.ReplaceAll(escapeDesktop(value), ";", `\;`) + ";")
+	}
+	return joined.String()
+}
+
+// Returns the keys to look up for a locale in order, following the matching rules of the desktop entry specification.
+// A locale has the form lang_COUNTRY.ENCODING@MODIFIER, where every part but lang is optional and the encoding is ignored.
+func localeKeys(key, locale string) (keys []string) {
+	rest, modifier, hasModifier := strings.Cut(locale, "@")
+	rest, _, _ = strings.Cut(rest, ".")
+	lang, country, hasCountry := strings.Cut(rest, "_")
+	if hasCountry && hasModifier {
+		keys = append(keys, key+"["+lang+"_"+country+"@"+modifier+"]")
+	}
+	if hasCountry {
+		keys = append(keys, key+"["+lang+"_"+country+"]")
+	}
+	if hasModifier {
+		keys = append(keys, key+"["+lang+"@"+modifier+"]")
+	}
+	if lang != "" {
+		keys = append(keys, key+"["+lang+"]")
+	}
+	return append(keys, key)
+}
+
+// Looks up a localized value for a key, as in Name[de], along with a boolean result similar to a map lookup.
+// The locale, such as "de_DE.UTF-8", falls back to less specific locales and then to the key without a locale.
+func (f *file) GetLocale(section, key, locale string) (value string, ok bool) {
+	for _, localized := range localeKeys(key, locale) {
+		if value, ok = f.Get(section, localized); ok {
+			return
+		}
+	}
+	return
+}
+
+// Lists the locales that a key is localized for in a section, such as "de" for Name[de]
+func (f *file) Locales(section, key string) (value []string) {
+	for _, name := range f.lookup(section).keys {
+		if locale, ok := strings.CutPrefix(name, key+"["); ok && strings.HasSuffix(locale, "]") {
+			value = append(value, locale[:len(locale)-1])
+		}
+	}
+	return
+}
+
+// Set the value of a key for a locale, as in Name[de]. An empty locale sets the key itself.
+func (f *file) SetLocale(section, key, locale, value string) (ok bool) {
+	if locale != "" {
+		key += "[" + locale + "]"
+	}
+	return f.Set(section, key, value)
+}
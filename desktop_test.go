@@ -0,0 +1,65 @@
+package ini
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDesktop(t *testing.T) {
+	src := `[Desktop Entry]
+# A comment
+Type=Application
+Name=Files
+Name[de]=Dateien
+Name[sr@latin]=Datoteke
+Name[pt_BR]=Arquivos
+Comment=Browse\sfiles\nand folders
+Categories=Utility;Core;
+Keywords=folder;a\;b;back\\;
+Exec=nautilus ; --new-window
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Dialect: DialectDesktop})
+	if err != nil {
+		t.Fatal(err)
+	}
+	locales := map[string]string{
+		"de_DE.UTF-8":    "Dateien",
+		"de":             "Dateien",
+		"sr_RS@latin":    "Datoteke",
+		"pt_BR":          "Arquivos",
+		"pt_PT":          "Files",
+		"fr_FR@euro":     "Files",
+		"":               "Files",
+		"sr_RS.UTF-8@ij": "Files",
+	}
+	for locale, expected := range locales {
+		if value, ok := file.GetLocale("Desktop Entry", "Name", locale); !ok || value != expected {
+			t.Errorf("GetLocale(%q): expected %q, got %q %v", locale, expected, value, ok)
+		}
+	}
+	if locales := file.Locales("Desktop Entry", "Name"); !reflect.DeepEqual(locales, []string{"de", "sr@latin", "pt_BR"}) {
+		t.Errorf("Locales: got %v", locales)
+	}
+	checkStr(t, file, "Desktop Entry", "Comment", "Browse files\nand folders")
+	checkStr(t, file, "Desktop Entry", "Exec", "nautilus ; --new-window")
+	checkArr(t, file, "Desktop Entry", "Categories", []string{"Utility", "Core"})
+	checkArr(t, file, "Desktop Entry", "Keywords", []string{"folder", "a;b", `back\`})
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected an unchanged round trip; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	file.SetLocale("Desktop Entry", "Name", "fr", "Fichiers")
+	file.Set("Desktop Entry", "Comment", " Tabs\tand\\slashes")
+	file.SetArr("Desktop Entry", "Categories", []string{"Utility", "x;y"})
+	expected := strings.NewReplacer(
+		`Comment=Browse\sfiles\nand folders`, `Comment=\sTabs\tand\\slashes`,
+		"Categories=Utility;Core;", `Categories=Utility;x\;y;`,
+		"Exec=nautilus ; --new-window\n", "Exec=nautilus ; --new-window\nName[fr]=Fichiers\n",
+	).Replace(src)
+	if out := writeString(t, file); out != expected {
+		t.Errorf("expected values to be escaped; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	checkStr(t, file, "Desktop Entry", "Comment", " Tabs\tand\\slashes")
+	checkArr(t, file, "Desktop Entry", "Categories", []string{"Utility", "x;y"})
+}
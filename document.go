@@ -0,0 +1,550 @@
+package ini
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type lineKind int
+
+const (
+	blankLine lineKind = iota
+	commentLine
+	sectionLine
+	valueLine
+	arrayLine
+	mapLine       // An entry in a PHP style map, as in `key[index] = value`
+	listLine      // An array written as an inline list, as in `key = [a, b]`
+	directiveLine // An include directive, such as `!include path`
+)
+
+// A line is a single entry in the document behind a File.
+// The concatenation of prefix, value, suffix and eol is exactly the text the line was parsed from,
+// so an unmodified document is written back byte for byte and a Set only replaces the value.
+type line struct {
+	kind     lineKind
+	comments []*line // Comment lines directly above a section header or a value
+	key      string  // The section name or key, without surrounding whitespace
+	prefix   string  // Everything before the value, e.g. indentation, the key and the delimiter
+	value    string  // The value as written, including any quotes
+	suffix   string  // Everything after the value, e.g. trailing whitespace
+	eol      string  // The line terminator, empty for a final line without one
+	edited   bool    // The value was set rather than read, so it may be laid out afresh
+	bare     bool    // The key has no delimiter after it, as in `key <<EOF`
+	index    string  // The index in the brackets after the key of a map entry
+}
+
+// Whether the line holds a value for a key
+func (l *line) isValue() bool {
+	return l.kind == valueLine || l.kind == arrayLine || l.kind == mapLine || l.kind == listLine
+}
+
+func (l *line) text() string {
+	return l.prefix + l.value + l.suffix + l.eol
+}
+
+// Replace the value of a line, leaving the rest of its layout alone, including the quotes around the value
+func (f *file) setValue(l *line, value string) {
+	if l.bare {
+		l.prefix, l.bare = indentOf(l)+l.key+f.delimiter(), false
+	}
+	text := f.formatQuoted(value, quoteOf(l.value))
+	if f.literalValues() {
+		text = value
+	}
+	if l.value == "" && text != "" && len(l.prefix) > 1 && l.prefix[len(l.prefix)-2] == ' ' {
+		// `key =` was written with a space before the delimiter, so keep it balanced
+		l.prefix += " "
+	}
+	if l.value == "" && text != "" && f.commentPrefix(l.suffix) != "" {
+		// An inline comment straight after an empty value needs space before it once there is a value
+		l.suffix = " " + l.suffix
+	}
+	l.value = f.emptyValue(l.prefix, text)
+	l.edited = true
+}
+
+// The delimiter written between a new key and its value. Unless WriteOptions.Delimiter is set, this is the first
+// of the delimiters the file is read with, so that the file reads back the same.
+func (f *file) delimiter() string {
+	if f.writeOptions.Delimiter != "" {
+		return f.writeOptions.Delimiter
+	}
+	if f.literalValues() {
+		return "="
+	}
+	if len(f.parseOptions.Delimiters) == 0 {
+		return " = "
+	}
+	switch first := f.parseOptions.Delimiters[0]; {
+	case strings.TrimSpace(first) == "":
+		return " "
+	case first == "=":
+		return " = "
+	default:
+		return first + " "
+	}
+}
+
+// An empty value after a key that is only followed by whitespace is quoted, so that it is still read as a value
+func (f *file) emptyValue(prefix, text string) string {
+	if text != "" {
+		return text
+	}
+	delimiters, _ := f.delimiters()
+	for _, delimiter := range delimiters {
+		if strings.HasSuffix(strings.TrimSpace(prefix), delimiter) {
+			return text
+		}
+	}
+	return `""`
+}
+
+// Whether a value would be read back differently if it were written as it is
+func (f *file) needsQuotes(value string) bool {
+	if value == "" {
+		return false
+	}
+	if value != strings.TrimSpace(value) || value[0] == '"' || value[0] == '\'' || continues(value) {
+		return true
+	}
+	if f.parseOptions.InlineComments && f.inlineCommentStart(value) >= 0 {
+		return true
+	}
+	if f.parseOptions.Dialect == DialectPHP && strings.ContainsAny(value, phpSpecialChars) {
+		return true
+	}
+	if f.parseOptions.MultilineValues && heredocStartRegex.MatchString(value) {
+		// The value would be read as the start of a heredoc
+		return true
+	}
+	if f.parseOptions.InlineLists && value[0] == '[' {
+		// The value would be read as a list
+		return true
+	}
+	return strings.IndexFunc(value, unicode.IsControl) >= 0
+}
+
+// Quote a value in double quotes, escaping anything that would not otherwise be read back the same
+func quoteValue(value string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\\', '"':
+			quoted.WriteByte('\\')
+			quoted.WriteRune(r)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&quoted, `\u%04x`, r)
+			} else {
+				quoted.WriteRune(r)
+			}
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// The text to write for a value
+func (f *file) formatValue(value string) string {
+	if f.literalValues() {
+		// systemd leaves quotes and escapes to the settings that use them, and desktop entries are escaped when set
+		return value
+	}
+	if f.parseOptions.Dialect == DialectPHP && f.needsQuotes(value) {
+		return phpQuote(value)
+	}
+	if f.parseOptions.MultilineValues && strings.Contains(value, "\n") {
+		if block, ok := f.blockValue(value); ok {
+			return block
+		}
+	}
+	if f.needsQuotes(value) {
+		return quoteValue(value)
+	}
+	return value
+}
+
+// Whether a folded value may be split before the byte at pos without changing it when read back.
+// The next line must not start with whitespace, which is dropped, and the split must not escape the continuation.
+func canFold(value string, pos int) bool {
+	return !unicode.IsSpace(rune(value[pos])) && utf8.RuneStart(value[pos]) && value[pos-1] != '\\'
+}
+
+// Fold a value onto continuation lines, so that each line is no longer than width where that is possible.
+// The first line already has lead bytes before the value, and following lines are indented with indent.
+func foldValue(value string, lead, width int, indent, eol string) string {
+	var folded strings.Builder
+	for lead+len(value) > width {
+		limit := width - lead - 1
+		cut, hard := 0, 0
+		for pos := 1; pos < len(value) && pos <= limit; pos++ {
+			if !canFold(value, pos) {
+				continue
+			}
+			hard = pos
+			if value[pos-1] == ' ' || value[pos-1] == '\t' {
+				cut = pos
+			}
+		}
+		if cut == 0 {
+			cut = hard
+		}
+		for pos := limit + 1; cut == 0 && pos < len(value); pos++ {
+			if pos > 0 && canFold(value, pos) {
+				cut = pos
+			}
+		}
+		if cut == 0 {
+			break
+		}
+		folded.WriteString(value[:cut] + "\\" + eol + indent)
+		value = value[cut:]
+		lead = len(indent)
+	}
+	folded.WriteString(value)
+	return folded.String()
+}
+
+// A block is a section header followed by the lines up to the next header.
+// A section that appears more than once in a source has a block for each appearance.
+// The first block of a document is the default section and has no header.
+type block struct {
+	name   string // The section name as it is compared, see ParseOptions.Normalize
+	header *line
+	lines  []*line
+}
+
+func (b *block) lastLine() *line {
+	if len(b.lines) > 0 {
+		return b.lines[len(b.lines)-1]
+	}
+	return b.header
+}
+
+// Insert a new value line after the last value in the block, or otherwise before any trailing blank lines
+func (b *block) insert(l *line) {
+	if b.header == nil && len(b.lines) == 0 {
+		// Keep the default section apart from the first header
+		b.lines = []*line{l, {kind: blankLine, eol: l.eol}}
+		return
+	}
+	pos := -1
+	for i, cur := range b.lines {
+		if cur.isValue() {
+			pos = i + 1
+		}
+	}
+	if pos < 0 {
+		pos = len(b.lines)
+		for pos > 0 && b.lines[pos-1].kind == blankLine {
+			pos--
+		}
+	}
+	b.insertAt(pos, l)
+}
+
+func (b *block) insertAt(pos int, l *line) {
+	b.lines = append(b.lines, nil)
+	copy(b.lines[pos+1:], b.lines[pos:])
+	b.lines[pos] = l
+}
+
+func (b *block) remove(pos int) {
+	b.lines = append(b.lines[:pos], b.lines[pos+1:]...)
+}
+
+// The default section block, which is always the first block in the document
+func (f *file) defaultBlock() *block {
+	if len(f.blocks) == 0 {
+		f.blocks = []*block{{}}
+	}
+	return f.blocks[0]
+}
+
+// The line terminator used for new lines; this follows the source where there is one
+func (f *file) lineEnding() string {
+	if f.eol == "" {
+		return "\n"
+	}
+	return f.eol
+}
+
+// Returns the last block for a section, appending a new block with a header if there is none
+func (f *file) lastBlock(section string) *block {
+	f.defaultBlock()
+	if section == "" {
+		return f.blocks[0]
+	}
+	for i := len(f.blocks) - 1; i > 0; i-- {
+		if f.blocks[i].name == f.norm(section) {
+			return f.blocks[i]
+		}
+	}
+	eol := f.lineEnding()
+	prev := f.blocks[len(f.blocks)-1]
+	if last := prev.lastLine(); last != nil && last.kind != blankLine {
+		prev.lines = append(prev.lines, &line{kind: blankLine, eol: eol})
+	}
+	newBlock := &block{
+		name:   f.norm(section),
+		header: f.newHeader(section),
+		lines:  []*line{{kind: blankLine, eol: eol}},
+	}
+	f.blocks = append(f.blocks, newBlock)
+	return newBlock
+}
+
+// Returns the lines of the given kind for a key in a section, in document order
+func (f *file) keyLines(section, key string, kind lineKind) (lines []*line) {
+	for _, b := range f.blocks {
+		if b.name != f.norm(section) {
+			continue
+		}
+		for _, l := range b.lines {
+			if l.kind == kind && f.norm(l.key) == f.norm(key) {
+				lines = append(lines, l)
+			}
+		}
+	}
+	return
+}
+
+// Returns the header of the first block for a section, or a new header if the section has no block
+func (f *file) headerLine(section string) *line {
+	for _, b := range f.blocks {
+		if b.header != nil && b.name == f.norm(section) {
+			return b.header
+		}
+	}
+	return f.newHeader(section)
+}
+
+func (f *file) newHeader(section string) *line {
+	return &line{kind: sectionLine, key: section, prefix: "[" + section + "]", eol: f.lineEnding()}
+}
+
+func (f *file) newValueLine(kind lineKind, key, value string) *line {
+	prefix := key + f.delimiter()
+	if kind == arrayLine {
+		prefix = key + "[]" + f.delimiter()
+	}
+	return &line{kind: kind, key: key, prefix: prefix, value: f.emptyValue(prefix, f.formatValue(value)), eol: f.lineEnding(), edited: true}
+}
+
+// Insert a line directly after another line in the document
+func (f *file) insertAfter(ref, l *line) {
+	for _, b := range f.blocks {
+		for i, cur := range b.lines {
+			if cur == ref {
+				b.insertAt(i+1, l)
+				return
+			}
+		}
+	}
+}
+
+// Returns the line holding the value in effect for a key that may be repeated, which depends on the duplicate key policy
+func (f *file) activeLine(lines []*line) *line {
+	if f.parseOptions.DuplicateKeys == DuplicateKeyFirstWins {
+		return lines[0]
+	}
+	return lines[len(lines)-1]
+}
+
+// Record a string value in the document, updating the line that holds it if there is one
+func (f *file) setLine(section, key, value string) {
+	lines := f.keyLines(section, key, valueLine)
+	if len(lines) > 0 {
+		f.setValue(f.activeLine(lines), value)
+		return
+	}
+	f.lastBlock(section).insert(f.newValueLine(valueLine, key, value))
+}
+
+// Record an array value in the document, reusing the existing lines for the key in order
+func (f *file) setArrLines(section, key string, values []string) {
+	kind := arrayLine
+	if f.parseOptions.DuplicateKeys == DuplicateKeyCollect && len(f.keyLines(section, key, valueLine)) > 1 ||
+		f.parseOptions.Dialect == DialectSystemd {
+		// An array collected from a repeated key keeps that form
+		kind = valueLine
+	}
+	if lists := f.keyLines(section, key, listLine); len(lists) > 0 && kind == arrayLine {
+		// The last list for a key is the one that was read
+		l := lists[len(lists)-1]
+		l.value, l.edited = f.formatList(values), true
+		return
+	}
+	lines := f.keyLines(section, key, kind)
+	first := f.lookup(section).firstIndex(key)
+	if f.writeOptions.InlineLists && kind == arrayLine && len(lines) == 0 && first < 0 {
+		f.lastBlock(section).insert(f.newListLine(key, values))
+		return
+	}
+	if first >= 0 {
+		active := activeIndexed(lines)
+		for _, l := range lines {
+			if !slices.Contains(active, l) {
+				// A value that was overridden by a later line for the same index
+				f.removeLine(l)
+			}
+		}
+		lines = active
+	}
+	if f.parseOptions.Dialect == DialectSystemd {
+		// The lines up to an empty assignment are not part of the list, so they are left alone
+		for i := len(lines) - 1; i >= 0; i-- {
+			if lines[i].value == "" {
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+	var prev *line
+	for i, value := range values {
+		if i < len(lines) {
+			f.setValue(lines[i], value)
+			prev = lines[i]
+			continue
+		}
+		l := f.newValueLine(kind, key, value)
+		if first >= 0 {
+			setIndex(l, first+i)
+		}
+		if prev == nil {
+			f.lastBlock(section).insert(l)
+		} else {
+			f.insertAfter(prev, l)
+		}
+		prev = l
+	}
+	for i := len(values); i < len(lines); i++ {
+		f.removeLine(lines[i])
+	}
+}
+
+func (f *file) removeLine(l *line) {
+	for _, b := range f.blocks {
+		for i, cur := range b.lines {
+			if cur == l {
+				b.remove(i)
+				return
+			}
+		}
+	}
+}
+
+// Remove every line for a key in a section
+func (f *file) removeLines(section, key string) {
+	for _, b := range f.blocks {
+		if b.name != f.norm(section) {
+			continue
+		}
+		kept := b.lines[:0]
+		for _, l := range b.lines {
+			if !l.isValue() || f.norm(l.key) != f.norm(key) {
+				kept = append(kept, l)
+			}
+		}
+		b.lines = kept
+	}
+}
+
+// Remove every block for a section.
+// The default section block is kept for its comments, but loses its values.
+func (f *file) removeBlocks(section string) {
+	kept := f.blocks[:0]
+	for i, b := range f.blocks {
+		if i > 0 && b.name == f.norm(section) {
+			continue
+		}
+		kept = append(kept, b)
+	}
+	f.blocks = kept
+	if section != "" || len(f.blocks) == 0 {
+		return
+	}
+	def := f.blocks[0]
+	onlyBlank := true
+	lines := def.lines[:0]
+	for _, l := range def.lines {
+		if l.isValue() {
+			continue
+		}
+		onlyBlank = onlyBlank && l.kind == blankLine
+		lines = append(lines, l)
+	}
+	def.lines = lines
+	if onlyBlank {
+		def.lines = nil
+	}
+}
+
+// A lineWriter writes document lines, keeping count of the bytes written and the first error
+type lineWriter struct {
+	out  io.Writer
+	n    int64
+	err  error
+	eol  string
+	open bool // The last line written had no terminator
+	fold int  // The width to fold edited values at, or zero
+
+	section string         // The section being written
+	indexes map[string]int // The next index of each array, when arrays are written with indexes
+}
+
+func (w *lineWriter) write(s string) {
+	if w.err != nil || s == "" {
+		return
+	}
+	var written int
+	written, w.err = io.WriteString(w.out, s)
+	w.n += int64(written)
+}
+
+func (w *lineWriter) line(l *line) {
+	if l == nil {
+		return
+	}
+	for _, comment := range l.comments {
+		w.line(comment)
+	}
+	if w.open {
+		// A line added after the end of the source needs the source to be terminated first
+		w.write(w.eol)
+	}
+	text := l.text()
+	if w.indexes != nil && l.kind == arrayLine && l.index == "" {
+		id := w.section + "\x00" + l.key
+		indexed := *l
+		setIndex(&indexed, w.indexes[id])
+		w.indexes[id]++
+		l, text = &indexed, indexed.text()
+	}
+	if w.fold > 0 && l.edited && len(l.prefix)+len(l.value) > w.fold && l.isValue() && l.kind != listLine &&
+		!strings.Contains(l.value, "\n") {
+		text = l.prefix + foldValue(l.value, len(l.prefix), w.fold, indentOf(l)+"    ", w.eol) + l.suffix + l.eol
+	}
+	w.write(text)
+	w.open = l.eol == ""
+}
+
+func (w *lineWriter) block(b *block) {
+	w.section = b.name
+	w.line(b.header)
+	for _, l := range b.lines {
+		w.line(l)
+	}
+}
@@ -0,0 +1,102 @@
+package ini
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var envNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+
+// MissingEnv is an environment variable that a value requires, as in ${DB_PASSWORD:?message}, but that is not set
+type MissingEnv struct {
+	Section string
+	Key     string
+	Name    string // The environment variable
+	Message string // The message given after :?, if any
+}
+
+func (m MissingEnv) String() string {
+	if m.Message == "" {
+		return fmt.Sprintf("%s (for %q in section [%s])", m.Name, m.Key, m.Section)
+	}
+	return fmt.Sprintf("%s (for %q in section [%s]): %s", m.Name, m.Key, m.Section, m.Message)
+}
+
+// ErrEnv is returned when values require environment variables that are not set, listing all of them
+type ErrEnv struct {
+	Missing []MissingEnv
+}
+
+func (e ErrEnv) Error() string {
+	missing := make([]string, len(e.Missing))
+	for i, m := range e.Missing {
+		missing[i] = m.String()
+	}
+	return "required environment variables are not set: " + strings.Join(missing, "; ")
+}
+
+// Split the contents of a reference to an environment variable, as in ${NAME}, ${NAME:-default} or ${NAME:?message}.
+// The `isEnv` boolean is false if the contents are not of that form.
+func envReference(inner string) (name, op, arg string, isEnv bool) {
+	name = envNameRegex.FindString(inner)
+	rest := inner[len(name):]
+	switch {
+	case name == "":
+		return "", "", "", false
+	case rest == "":
+		return name, "", "", true
+	case strings.HasPrefix(rest, ":-"), strings.HasPrefix(rest, ":?"):
+		return name, rest[:2], rest[2:], true
+	}
+	return "", "", "", false
+}
+
+// Looks up an environment variable, with ParseOptions.LookupEnv if it is set
+func (f *file) lookupEnv(name string) (string, bool) {
+	if f.parseOptions.LookupEnv != nil {
+		return f.parseOptions.LookupEnv(name)
+	}
+	return os.LookupEnv(name)
+}
+
+// Expand a reference to an environment variable in the value of a key.
+// A variable that is required but unset or empty is recorded as missing and expands to nothing.
+func (e *expander) env(section, key, name, op, arg string, escapes bool, chain []string) (string, error) {
+	value, _ := e.file.lookupEnv(name)
+	if value != "" {
+		return value, nil
+	}
+	switch op {
+	case ":-":
+		return e.text(section, key, arg, escapes, chain)
+	case ":?":
+		missing := MissingEnv{Section: section, Key: key, Name: name, Message: arg}
+		for _, m := range e.missing {
+			if m == missing {
+				// The key has been expanded before, through another reference
+				return "", nil
+			}
+		}
+		e.missing = append(e.missing, missing)
+	}
+	return "", nil
+}
+
+// Checks that every environment variable required by a value in the file is set.
+// This returns an ErrEnv listing all of those that are not, or an ErrReference if a reference to a key cannot be resolved.
+func (f *file) CheckEnv() error {
+	e := &expander{file: f, keys: f.parseOptions.Interpolation}
+	for _, section := range f.order {
+		for _, key := range f.lookup(section).keys {
+			if _, _, err := e.value(section, key, nil); err != nil {
+				return err
+			}
+		}
+	}
+	if e.missing != nil {
+		return ErrEnv{Missing: e.missing}
+	}
+	return nil
+}
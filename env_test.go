@@ -0,0 +1,75 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{"HOME": "/home/user", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+	src := `[app]
+data_dir = ${HOME}/data
+password = ${DB_PASSWORD:-changeme}
+fallback = ${EMPTY:-${HOME}}
+unset = [${NOT_SET}]
+cost = $$5
+required = ${API_KEY:?set the API key} ${SECRET:?}
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{ExpandEnv: true, LookupEnv: lookup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "app", "data_dir", "/home/user/data")
+	checkStr(t, file, "app", "password", "changeme")
+	checkStr(t, file, "app", "fallback", "/home/user")
+	checkStr(t, file, "app", "unset", "[]")
+	checkStr(t, file, "app", "cost", "$5")
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected values to be written unexpanded; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	if _, ok := file.Get("app", "required"); ok {
+		t.Error("expected Get to fail for a required variable that is not set")
+	}
+	expected := ErrEnv{Missing: []MissingEnv{
+		{Section: "app", Key: "required", Name: "API_KEY", Message: "set the API key"},
+		{Section: "app", Key: "required", Name: "SECRET"},
+	}}
+	err = file.CheckEnv()
+	var envErr ErrEnv
+	if !errors.As(err, &envErr) || !reflect.DeepEqual(envErr, expected) {
+		t.Errorf("expected %v, got %v", expected, err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "API_KEY") || !strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("expected the message to list every variable, got %q", err.Error())
+	}
+	env["API_KEY"], env["SECRET"] = "k", "s"
+	checkStr(t, file, "app", "required", "k s")
+	if err = file.CheckEnv(); err != nil {
+		t.Errorf("expected every variable to be set, got %v", err)
+	}
+
+	plain, err := LoadWithOptions(strings.NewReader(src), ParseOptions{LookupEnv: lookup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, plain, "app", "data_dir", "${HOME}/data")
+}
+
+func TestExpandEnvWithInterpolation(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		return map[string]string{"HOST": "env.example.com", "PORT": "9"}[name], name == "HOST" || name == "PORT"
+	}
+	src := "[server]\nHOST = ini.example.com\nurl = ${HOST}:${PORT}/${HOST:-x}\n"
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{ExpandEnv: true, Interpolation: true, LookupEnv: lookup})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "server", "url", "ini.example.com:9/env.example.com")
+}
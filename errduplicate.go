@@ -0,0 +1,18 @@
+package ini
+
+import "fmt"
+
+// ErrDuplicate is returned when a key or section appears more than once in an INI file and the parse options do not allow it.
+type ErrDuplicate struct {
+	Section   string
+	Key       string // The repeated key, or empty if the section header was repeated
+	Line      int    // The line of the repeat
+	FirstLine int    // The line the key or section first appeared on
+}
+
+func (e ErrDuplicate) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("duplicate INI section [%s] on line %d, first defined on line %d", e.Section, e.Line, e.FirstLine)
+	}
+	return fmt.Sprintf("duplicate INI key %q in section [%s] on line %d, first defined on line %d", e.Key, e.Section, e.Line, e.FirstLine)
+}
@@ -7,9 +7,14 @@ import (
 // This implements the full ini.StreamReadWriter interface
 type file struct {
 	sections                   map[string]*section
+	order                      []string // Section names in the order they were read or created
+	blocks                     []*block // The document, which keeps the layout of the source
+	eol                        string   // The line terminator of the source
 	reader                     io.Reader
 	environmentOverrideEnabled bool
 	environmentOverridePrefix  string
+	parseOptions               ParseOptions
+	writeOptions               WriteOptions
 }
 
 func (f *file) EnableEnvironmentVariableOverrides(prefix string) {
@@ -21,9 +26,17 @@ func (f *file) DisableEnvironmentVariableOverrides() {
 	f.environmentOverrideEnabled = false
 }
 
+// Returns the form that a section name or key is compared in, as given by the parse options
+func (f *file) norm(name string) string {
+	if f.parseOptions.Normalize == nil {
+		return name
+	}
+	return f.parseOptions.Normalize(name)
+}
+
 // Returns a named Section. A Section will be created if one does not already exist for the given name.
 func (f *file) section(name string) *section {
-	theSection := f.sections[name]
+	theSection := f.sections[f.norm(name)]
 	if theSection == nil {
 		theSection = &section{
 			file:         f,
@@ -31,25 +44,46 @@ func (f *file) section(name string) *section {
 			stringValues: make(map[string]string),
 			arrayValues:  make(map[string][]string),
 		}
-		f.sections[name] = theSection
+		f.sections[f.norm(name)] = theSection
+		f.order = append(f.order, name)
 	}
 	return theSection
 }
 
-func (f *file) Sections() (value []string) {
-	value = make([]string, 0, len(f.sections))
-	for sect, _ := range f.sections {
-		value = append(value, sect)
+// Returns a named Section for reading. A Section that does not exist is returned empty rather than created,
+// so that lookups do not add sections to the file.
+func (f *file) lookup(name string) *section {
+	if theSection := f.sections[f.norm(name)]; theSection != nil {
+		return theSection
 	}
+	return &section{file: f, name: name}
+}
+
+// Lists the sections in the file, in the order they were read or created
+func (f *file) Sections() (value []string) {
+	value = make([]string, len(f.order))
+	copy(value, f.order)
 	return
 }
 
+// Lists the keys in a section, including array keys, in the order they were read or set
+func (f *file) Keys(section string) (value []string) {
+	sect := f.lookup(section)
+	value = make([]string, len(sect.keys))
+	copy(value, sect.keys)
+	return
+}
+
+// Lists the values in a section, including any it inherits from other sections; use Keys for the order they appear in.
 func (f *file) Values(section string) (value map[string]string) {
 	value = make(map[string]string)
-	sect := f.section(section)
-	if sect != nil {
-		for k, v := range sect.stringValues {
-			value[k] = v
+	seen := make(map[string]bool)
+	for _, sect := range f.lookup(section).lineage() {
+		for _, k := range sect.keys {
+			if v, ok := sect.stringValues[f.norm(k)]; ok && !seen[f.norm(k)] {
+				seen[f.norm(k)] = true
+				value[k] = v
+			}
 		}
 	}
 	return
@@ -57,7 +91,13 @@ func (f *file) Values(section string) (value map[string]string) {
 
 // Looks up a value for a key in a section and returns that value, along with a boolean result similar to a map lookup.
 func (f *file) Get(section, key string) (value string, ok bool) {
-	return f.section(section).Get(key)
+	return f.lookup(section).Get(key)
+}
+
+// Reports whether a key in a section has a value, which may be empty.
+// This is false for a key that is missing or was read without a value, as allowed by ParseOptions.AllowNoValue.
+func (f *file) HasValue(section, key string) bool {
+	return f.lookup(section).HasValue(key)
 }
 
 // Set the value for a key in a section, along with a boolean result similar to a map lookup.
@@ -83,38 +123,53 @@ func (f *file) SetArr(section, key string, value []string) (ok bool) {
 // Looks up a value for a key in a section and returns that value, along with a boolean result similar to a map lookup.
 // The `ok` boolean will be false in the event that the value could not be parsed as an int
 func (f *file) GetInt(section, key string) (value int, ok bool) {
-	return f.section(section).GetInt(key)
+	return f.lookup(section).GetInt(key)
 }
 
 // Looks up a value for a key in a section and returns that value, along with a boolean result similar to a map lookup.
 // The `ok` boolean will be false in the event that the value could not be parsed as a bool
 func (f *file) GetBool(section, key string) (value bool, ok bool) {
-	return f.section(section).GetBool(key)
+	return f.lookup(section).GetBool(key)
 }
 
 // Looks up a value for an array key in a section and returns that value, along with a boolean result similar to a map lookup.
 func (f *file) GetArr(section, key string) (value []string, ok bool) {
-	return f.section(section).GetArr(key)
+	return f.lookup(section).GetArr(key)
 }
 
 func (f *file) Remove(section, key string) {
-	f.section(section).Remove(key)
+	f.lookup(section).Remove(key)
 }
 
 func (f *file) RemoveSection(section string) {
-	_, found := f.sections[section]
+	_, found := f.sections[f.norm(section)]
 	if found {
-		delete(f.sections, section)
+		delete(f.sections, f.norm(section))
+		for i, name := range f.order {
+			if f.norm(name) == f.norm(section) {
+				f.order = append(f.order[:i], f.order[i+1:]...)
+				break
+			}
+		}
 	}
+	f.removeBlocks(section)
 }
 
 func (f *file) Copy(w Setter) {
-	for secName, sec := range f.sections {
-		for keyName, val := range sec.stringValues {
-			w.Set(secName, keyName, val)
-		}
-		for keyName, arVal := range sec.arrayValues {
-			w.SetArr(secName, keyName, arVal)
+	for _, secName := range f.order {
+		sec := f.sections[f.norm(secName)]
+		for _, keyName := range sec.keys {
+			if val, ok := sec.stringValues[f.norm(keyName)]; ok {
+				w.Set(secName, keyName, val)
+			}
+			if arVal, ok := sec.arrayValues[f.norm(keyName)]; ok {
+				w.SetArr(secName, keyName, arVal)
+			}
+			if mapVal, ok := sec.mapValues[f.norm(keyName)]; ok {
+				if mapSetter, isMapSetter := w.(MapSetter); isMapSetter {
+					mapSetter.SetMap(secName, keyName, mapVal)
+				}
+			}
 		}
 	}
 }
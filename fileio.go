@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
-	"fmt"
 	"io"
 	"os"
 	"sort"
@@ -14,8 +13,8 @@ import (
 // Loads INI data from a reader and stores the data in the File.
 func (f *file) ReadFrom(in io.Reader) (n int64, err error) {
 	n = 0
-	scanner := bufio.NewScanner(in)
-	n, err = parseFile(scanner, f)
+	reader := bufio.NewReader(in)
+	n, err = parseFile(reader, f, "")
 	return
 }
 
@@ -26,65 +25,121 @@ func (f *file) LoadFile(file string) (err error) {
 		return
 	}
 	defer in.Close()
-	_, err = f.ReadFrom(in)
+	_, err = f.readFile(in, file)
 	return
 }
 
+// Loads INI data from an open file, whose path is used to find the files it includes
+func (f *file) readFile(in io.Reader, path string) (n int64, err error) {
+	return parseFile(bufio.NewReader(in), f, path)
+}
+
 // Write out an INI File representing the current state to a writer.
+// Lines read from a source are written back as they were read, so an unmodified File reproduces its source exactly.
 func (f *file) WriteTo(out io.Writer) (n int64, err error) {
-	orderedSections := make([]string, len(f.sections))
-	counter := 0
-	n = 0
-	thisWrite := 0
-	for section, _ := range f.sections {
-		orderedSections[counter] = section
-		counter++
+	w := &lineWriter{out: out, eol: f.lineEnding(), fold: f.writeOptions.FoldWidth}
+	if f.writeOptions.IndexedArrays {
+		w.indexes = make(map[string]int)
+	}
+	if f.writeOptions.Sort {
+		f.writeSorted(w)
+		return w.n, w.err
 	}
+	blocks := f.blocks
+	if f.writeOptions.InlineLists {
+		blocks = f.inlineBlocks()
+	}
+	for _, b := range blocks {
+		w.block(b)
+	}
+	return w.n, w.err
+}
+
+// Write the sections and keys in alphabetical order, reusing the lines of the document where there are any
+func (f *file) writeSorted(w *lineWriter) {
+	orderedSections := make([]string, len(f.order))
+	copy(orderedSections, f.order)
 	sort.Strings(orderedSections)
 	for _, section := range orderedSections {
-		options := f.sections[section]
-		thisWrite, err = fmt.Fprintln(out, "["+section+"]")
-		n += int64(thisWrite)
-		if (err) != nil {
-			return
-		}
-		orderedStringKeys := make([]string, len(options.stringValues))
-		counter = 0
-		for key, _ := range options.stringValues {
-			orderedStringKeys[counter] = key
-			counter++
+		options := f.sections[f.norm(section)]
+		w.section = f.norm(section)
+		if section != "" {
+			w.line(f.headerLine(section))
 		}
-		sort.Strings(orderedStringKeys)
-		for _, key := range orderedStringKeys {
-			thisWrite, err = fmt.Fprintln(out, key, "=", options.stringValues[key])
-			n += int64(thisWrite)
-			if (err) != nil {
-				return
+		for _, b := range f.blocks {
+			for _, l := range b.lines {
+				if b.name == f.norm(section) && l.kind == directiveLine {
+					w.line(l)
+				}
 			}
 		}
-		orderedArrayKeys := make([]string, len(options.arrayValues))
-		counter = 0
-		for key, _ := range options.arrayValues {
-			orderedArrayKeys[counter] = key
-			counter++
+		orderedKeys := make([]string, len(options.keys))
+		copy(orderedKeys, options.keys)
+		sort.Strings(orderedKeys)
+		for _, key := range orderedKeys {
+			if _, found := options.stringValues[f.norm(key)]; !found {
+				continue
+			}
+			// A value without a line was read from an included file, so it is not written
+			lines := f.keyLines(section, key, valueLine)
+			if len(lines) > 1 && (f.parseOptions.DuplicateKeys == DuplicateKeyCollect || f.parseOptions.Dialect == DialectSystemd) {
+				for _, l := range lines {
+					w.line(l)
+				}
+			} else if len(lines) > 0 {
+				w.line(f.activeLine(lines))
+			}
 		}
-		sort.Strings(orderedArrayKeys)
-		for _, key := range orderedArrayKeys {
-			for _, value := range options.arrayValues[key] {
-				thisWrite, err = fmt.Fprintln(out, key, "[]=", value)
-				n += int64(thisWrite)
-				if (err) != nil {
-					return
+		for _, key := range orderedKeys {
+			values, found := options.arrayValues[f.norm(key)]
+			if !found {
+				continue
+			}
+			if lists := f.keyLines(section, key, listLine); len(lists) > 0 {
+				w.line(lists[len(lists)-1])
+				continue
+			}
+			lines := f.keyLines(section, key, arrayLine)
+			if len(lines) == 0 {
+				// The array was collected from repeated keys, which are written above, or read from an included file
+				continue
+			}
+			first := options.firstIndex(key)
+			if first >= 0 {
+				lines = activeIndexed(lines)
+			}
+			if len(lines) == len(values) && f.writeOptions.InlineLists {
+				list := f.newListLine(key, values)
+				list.comments = lines[0].comments
+				w.line(list)
+				continue
+			}
+			if len(lines) == len(values) {
+				for _, l := range lines {
+					w.line(l)
 				}
+				continue
+			}
+			for i, value := range values {
+				l := f.newValueLine(arrayLine, key, value)
+				if first >= 0 {
+					setIndex(l, first+i)
+				}
+				w.line(l)
 			}
 		}
-		thisWrite, err = fmt.Fprintln(out)
-		n += int64(thisWrite)
-		if (err) != nil {
-			return
+		for _, key := range orderedKeys {
+			if _, found := options.mapValues[f.norm(key)]; !found {
+				continue
+			}
+			lines := f.keyLines(section, key, mapLine)
+			sort.SliceStable(lines, func(i, j int) bool { return lines[i].index < lines[j].index })
+			for _, l := range lines {
+				w.line(l)
+			}
 		}
+		w.line(&line{kind: blankLine, eol: f.lineEnding()})
 	}
-	return
 }
 
 // Load ini data from the bytestream provided
@@ -0,0 +1,217 @@
+package ini
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrIncludeCycle is the cause of an ErrInclude for a file that includes itself, directly or through other files
+	ErrIncludeCycle = errors.New("include cycle")
+	// ErrIncludeDepth is the cause of an ErrInclude for includes nested more deeply than ParseOptions.IncludeDepth
+	ErrIncludeDepth = errors.New("includes nested too deeply")
+	// ErrIncludeOutsideRoot is the cause of an ErrInclude for a file outside ParseOptions.IncludeRoot
+	ErrIncludeOutsideRoot = errors.New("include outside of the allowed root")
+)
+
+// The nesting of includes allowed when ParseOptions.IncludeDepth is not set
+const defaultIncludeDepth = 10
+
+// The extensions of the files read from a directory by !includedir
+var includeDirExtensions = []string{".cnf", ".conf", ".ini"}
+
+// An IncludeSite is the place an include directive was read from
+type IncludeSite struct {
+	File string // The including file, or empty if it was read from a reader rather than a named file
+	Line int
+}
+
+func (s IncludeSite) String() string {
+	if s.File == "" {
+		return fmt.Sprintf("line %d", s.Line)
+	}
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// ErrInclude is returned when a file named by an include directive cannot be read.
+// The cause, such as ErrIncludeCycle, an ErrSyntax in the included file or an error opening it, is available through errors.Is and errors.As.
+type ErrInclude struct {
+	Path  string        // The file that could not be included
+	Chain []IncludeSite // The include directives that led to the file, outermost first
+	Err   error
+}
+
+func (e ErrInclude) Error() string {
+	chain := make([]string, len(e.Chain))
+	for i, site := range e.Chain {
+		chain[i] = site.String()
+	}
+	return fmt.Sprintf("cannot include %s: %v (included from %s)", e.Path, e.Err, strings.Join(chain, " -> "))
+}
+
+func (e ErrInclude) Unwrap() error {
+	return e.Err
+}
+
+// Read the file and the path of an include directive, such as `!include path` or `!includedir dir`
+func includeDirective(trimmed string) (directive, path string, ok bool) {
+	directive, path, _ = strings.Cut(trimmed, " ")
+	if directive != "!include" && directive != "!includedir" {
+		return "", "", false
+	}
+	return directive, trimWithQuotes(path), true
+}
+
+// Whether a key is the git style `[include] path = ...`
+func (p *parser) isIncludeKey(key string) bool {
+	return p.block.name == p.file.norm("include") && p.file.norm(key) == p.file.norm("path")
+}
+
+// Returns the path a directive refers to, which is relative to the directory of the including file
+func (p *parser) resolve(path string) string {
+	if filepath.IsAbs(path) || p.path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(p.path), path)
+}
+
+// Read the files that an include directive on a line refers to
+func (p *parser) readInclude(directive, path string, lineNum int) error {
+	path = p.resolve(path)
+	if directive == "!include" {
+		return p.include(path, lineNum)
+	}
+	if err := p.checkInclude(path, lineNum); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return p.includeError(path, lineNum, err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		for _, allowed := range includeDirExtensions {
+			if !entry.IsDir() && ext == allowed {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err = p.include(filepath.Join(path, name), lineNum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *parser) includeError(path string, lineNum int, err error) error {
+	chain := append(p.chain[:len(p.chain):len(p.chain)], IncludeSite{File: p.path, Line: lineNum})
+	return ErrInclude{Path: path, Chain: chain, Err: err}
+}
+
+// Check that a path may be included from a line, given the includes that led to it and the parse options
+func (p *parser) checkInclude(path string, lineNum int) error {
+	depth := p.file.parseOptions.IncludeDepth
+	if depth == 0 {
+		depth = defaultIncludeDepth
+	}
+	if len(p.chain) >= depth {
+		return p.includeError(path, lineNum, ErrIncludeDepth)
+	}
+	if root := p.file.parseOptions.IncludeRoot; root != "" && !within(root, path) {
+		return p.includeError(path, lineNum, ErrIncludeOutsideRoot)
+	}
+	target := realPath(path)
+	if target == realPath(p.path) {
+		return p.includeError(path, lineNum, ErrIncludeCycle)
+	}
+	for _, site := range p.chain {
+		if target == realPath(site.File) {
+			return p.includeError(path, lineNum, ErrIncludeCycle)
+		}
+	}
+	return nil
+}
+
+// Read an included file into the values of the file being parsed.
+// The included file starts in the default section, and the lines it is read from are not part of the document.
+func (p *parser) include(path string, lineNum int) error {
+	if err := p.checkInclude(path, lineNum); err != nil {
+		return err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return p.includeError(path, lineNum, err)
+	}
+	defer in.Close()
+	nested := &parser{
+		file:      p.file,
+		in:        bufio.NewReader(in),
+		block:     &block{},
+		path:      path,
+		chain:     append(p.chain[:len(p.chain):len(p.chain)], IncludeSite{File: p.path, Line: lineNum}),
+		detached:  true,
+		sections:  p.sections,
+		keys:      p.keys,
+		collected: p.collected,
+		lists:     p.lists,
+	}
+	if _, err = nested.run(); err != nil {
+		if _, isInclude := err.(ErrInclude); isInclude {
+			return err
+		}
+		return p.includeError(path, lineNum, err)
+	}
+	return nil
+}
+
+// Read a file into the values of the file, without its lines becoming part of the document
+func (f *file) readDetached(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	p := &parser{
+		file:      f,
+		in:        bufio.NewReader(in),
+		block:     &block{},
+		path:      path,
+		detached:  true,
+		sections:  make(map[string]int),
+		keys:      make(map[string]int),
+		collected: make(map[string]bool),
+		lists:     make(map[string]bool),
+	}
+	if _, err = p.run(); err != nil {
+		return err
+	}
+	return f.placeIndexed()
+}
+
+// Returns an absolute path with any symbolic links resolved, for comparing paths that may be spelled differently
+func realPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return path
+}
+
+// Whether a path is inside a root directory, after symbolic links are followed
+func within(root, path string) bool {
+	rel, err := filepath.Rel(realPath(root), realPath(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
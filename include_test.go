@@ -0,0 +1,113 @@
+package ini
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"my.cnf":              "[mysqld]\nport = 3306\n!include common/base.cnf\n!includedir conf.d\nuser = mysql\n",
+		"common/base.cnf":     "[mysqld]\nuser = nobody\nsocket = /tmp/mysql.sock\n",
+		"conf.d/10-tune.cnf":  "[mysqld]\nmax_connections = 100\n",
+		"conf.d/20-tune.cnf":  "[mysqld]\nmax_connections = 200\n",
+		"conf.d/README":       "not a config file\n",
+		"git.ini":             "[include]\npath = common/git-base.ini\n[user]\nname = Someone\n",
+		"common/git-base.ini": "[core]\neditor = vi\n",
+	})
+	opts := ParseOptions{Includes: true}
+
+	file, err := LoadFileWithOptions(filepath.Join(dir, "my.cnf"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "mysqld", "port", "3306")
+	checkStr(t, file, "mysqld", "user", "mysql")
+	checkStr(t, file, "mysqld", "socket", "/tmp/mysql.sock")
+	checkStr(t, file, "mysqld", "max_connections", "200")
+	if info, ok := file.GetInfo("mysqld", "socket"); ok {
+		t.Errorf("expected no information for an included value, got %+v", info)
+	}
+	src, _ := os.ReadFile(filepath.Join(dir, "my.cnf"))
+	if out := writeString(t, file); out != string(src) {
+		t.Errorf("expected included values not to be written; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	file, err = LoadFileWithOptions(filepath.Join(dir, "git.ini"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "core", "editor", "vi")
+	checkStr(t, file, "user", "name", "Someone")
+
+	if _, err = LoadFile(filepath.Join(dir, "my.cnf")); err == nil {
+		t.Error("expected include directives to be a syntax error by default")
+	}
+}
+
+func TestIncludeErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.ini":       "x = 1\n!include b.ini\n",
+		"b.ini":       "\n\n!include a.ini\n",
+		"deep.ini":    "!include deep2.ini\n",
+		"deep2.ini":   "!include deep3.ini\n",
+		"deep3.ini":   "y = 1\n",
+		"bad.ini":     "!include broken.ini\n",
+		"broken.ini":  "ok = 1\nnot valid\n",
+		"escape.ini":  "!include ../outside.ini\n",
+		"missing.ini": "!include nowhere.ini\n",
+	})
+
+	_, err := LoadFileWithOptions(filepath.Join(dir, "a.ini"), ParseOptions{Includes: true})
+	var includeErr ErrInclude
+	if !errors.Is(err, ErrIncludeCycle) || !errors.As(err, &includeErr) {
+		t.Fatalf("expected an include cycle, got %v", err)
+	}
+	if len(includeErr.Chain) != 2 || includeErr.Chain[0].Line != 2 || includeErr.Chain[1].Line != 3 {
+		t.Errorf("expected the chain to give the lines of both directives, got %v", includeErr.Chain)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "a.ini:2 -> ") || !strings.Contains(msg, "b.ini:3") {
+		t.Errorf("expected the message to show the chain, got %q", msg)
+	}
+
+	_, err = LoadFileWithOptions(filepath.Join(dir, "deep.ini"), ParseOptions{Includes: true, IncludeDepth: 1})
+	if !errors.Is(err, ErrIncludeDepth) {
+		t.Errorf("expected the depth to be limited, got %v", err)
+	}
+	if _, err = LoadFileWithOptions(filepath.Join(dir, "deep.ini"), ParseOptions{Includes: true}); err != nil {
+		t.Errorf("expected the default depth to allow nesting, got %v", err)
+	}
+
+	_, err = LoadFileWithOptions(filepath.Join(dir, "bad.ini"), ParseOptions{Includes: true})
+	var syntaxErr ErrSyntax
+	if !errors.As(err, &syntaxErr) || syntaxErr.Line != 2 {
+		t.Errorf("expected a syntax error in the included file, got %v", err)
+	}
+
+	_, err = LoadFileWithOptions(filepath.Join(dir, "escape.ini"), ParseOptions{Includes: true, IncludeRoot: dir})
+	if !errors.Is(err, ErrIncludeOutsideRoot) {
+		t.Errorf("expected an include outside the root to fail, got %v", err)
+	}
+
+	_, err = LoadFileWithOptions(filepath.Join(dir, "missing.ini"), ParseOptions{Includes: true})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected a missing include to fail, got %v", err)
+	}
+}
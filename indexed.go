@@ -0,0 +1,124 @@
+package ini
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrIndex is returned when the indexes of an array, as in key[0] = value, leave a gap
+type ErrIndex struct {
+	Section string
+	Key     string
+	Index   int // The first index that has no value
+}
+
+func (e ErrIndex) Error() string {
+	return fmt.Sprintf("INI array %q in section [%s] has no value at index %d", e.Key, e.Section, e.Index)
+}
+
+// Store a value for an index of an array, as in key[0] = value. A later value for the same index replaces an earlier one,
+// so that a file read later can override a single value. An array may not mix indexes with key[] = value.
+func (p *parser) storeIndexed(sect *section, l *line, value string, lineNum int) error {
+	key := p.file.norm(l.key)
+	if _, found := sect.arrayValues[key]; found && sect.indexed[key] == nil {
+		return ErrSyntax{lineNum, strings.TrimSpace(l.prefix)}
+	}
+	if sect.indexed == nil {
+		sect.indexed = make(map[string]map[int]string)
+	}
+	if sect.indexed[key] == nil {
+		sect.indexed[key] = make(map[int]string)
+		sect.arrayValues[key] = nil
+	}
+	index, _ := strconv.Atoi(l.index)
+	sect.indexed[key][index] = value
+	return nil
+}
+
+// Returns the sorted indexes of an indexed array
+func sortedIndexes(indexed map[int]string) []int {
+	indexes := make([]int, 0, len(indexed))
+	for index := range indexed {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// Build the arrays that were read with indexes. As with environment variable overrides, the first index may be 0 or 1,
+// and the rest must follow it without gaps.
+func (f *file) placeIndexed() error {
+	for _, name := range f.order {
+		sect := f.lookup(name)
+		for _, key := range sect.keys {
+			indexed := sect.indexed[f.norm(key)]
+			if indexed == nil {
+				continue
+			}
+			indexes := sortedIndexes(indexed)
+			first := indexes[0]
+			if first > 1 {
+				return ErrIndex{Section: name, Key: key, Index: 0}
+			}
+			values := make([]string, len(indexes))
+			for i, index := range indexes {
+				if index != first+i {
+					return ErrIndex{Section: name, Key: key, Index: first + i}
+				}
+				values[i] = indexed[index]
+			}
+			sect.arrayValues[f.norm(key)] = values
+		}
+	}
+	return nil
+}
+
+// The index that an array written with indexes starts at, or -1 if the array is not written with indexes
+func (s *section) firstIndex(key string) int {
+	indexed := s.indexed[s.file.norm(key)]
+	if len(indexed) == 0 {
+		return -1
+	}
+	return sortedIndexes(indexed)[0]
+}
+
+// Record the values of an array that is written with indexes, starting at the index it started at before
+func (s *section) reindex(key string, values []string) {
+	first := s.firstIndex(key)
+	if first < 0 {
+		return
+	}
+	indexed := make(map[int]string, len(values))
+	for i, value := range values {
+		indexed[first+i] = value
+	}
+	s.indexed[s.file.norm(key)] = indexed
+}
+
+// Returns the lines of an array written with indexes, ordered by index. Where an index was written more than once,
+// only the last line is returned, as that is the one that was read.
+func activeIndexed(lines []*line) (active []*line) {
+	last := make(map[string]*line)
+	for _, l := range lines {
+		last[l.index] = l
+	}
+	for _, l := range lines {
+		if last[l.index] == l {
+			active = append(active, l)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		a, _ := strconv.Atoi(active[i].index)
+		b, _ := strconv.Atoi(active[j].index)
+		return a < b
+	})
+	return
+}
+
+// Give a new array line an index, as in key[0] = value
+func setIndex(l *line, index int) {
+	l.index = strconv.Itoa(index)
+	l.prefix = strings.Replace(l.prefix, "[]", "["+l.index+"]", 1)
+}
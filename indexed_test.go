@@ -0,0 +1,83 @@
+package ini
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIndexedArrays(t *testing.T) {
+	src := "[servers]\nhosts[1] = b.example.com\nhosts[0] = a.example.com\nports[1] = 80\nports[2] = 443\n"
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkArr(t, file, "servers", "hosts", []string{"a.example.com", "b.example.com"})
+	checkArr(t, file, "servers", "ports", []string{"80", "443"})
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected an unchanged round trip; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	file.SetArr("servers", "ports", []string{"80", "443", "8080"})
+	file.SetArr("servers", "hosts", []string{"c.example.com"})
+	expected := "[servers]\nhosts[0] = c.example.com\nports[1] = 80\nports[2] = 443\nports[3] = 8080\n"
+	if out := writeString(t, file); out != expected {
+		t.Errorf("got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+
+	for _, bad := range []struct {
+		src string
+		err error
+	}{
+		{"hosts[0] = a\nhosts[2] = c\n", ErrIndex{Key: "hosts", Index: 1}},
+		{"hosts[2] = c\n", ErrIndex{Key: "hosts", Index: 0}},
+		{"hosts[0] = a\nhosts[] = b\n", ErrSyntax{Line: 2, Source: "hosts[] ="}},
+	} {
+		if _, err := Load(strings.NewReader(bad.src)); err != bad.err {
+			t.Errorf("%q: expected %v, got %v", bad.src, bad.err, err)
+		}
+	}
+}
+
+func TestIndexedArrayOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.ini":  "[app]\nhosts[0] = a\nhosts[1] = b\n!include local.ini\n",
+		"local.ini": "[app]\nhosts[1] = local\n",
+	})
+	file, err := LoadFileWithOptions(filepath.Join(dir, "main.ini"), ParseOptions{Includes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkArr(t, file, "app", "hosts", []string{"a", "local"})
+
+	writeFiles(t, dir, map[string]string{"local.ini": "[app]\nhosts[3] = d\n"})
+	_, err = LoadFileWithOptions(filepath.Join(dir, "main.ini"), ParseOptions{Includes: true})
+	if !errors.Is(err, ErrIndex{Section: "app", Key: "hosts", Index: 2}) {
+		t.Errorf("expected a gap to be found across files, got %v", err)
+	}
+}
+
+func TestWriteIndexedArrays(t *testing.T) {
+	src := "[app]\nhosts[] = a\nhosts[] = b\n"
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.SetWriteOptions(WriteOptions{IndexedArrays: true})
+	file.SetArr("app", "ports", []string{"80"})
+	expected := "[app]\nhosts[0] = a\nhosts[1] = b\nports[0] = 80\n"
+	if out := writeString(t, file); out != expected {
+		t.Errorf("got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	file.SetWriteOptions(WriteOptions{IndexedArrays: true, Sort: true})
+	if out := writeString(t, file); out != expected+"\n" {
+		t.Errorf("sorted: got: <<<%s<<< expected <<<%s<<<", out, expected+"\n")
+	}
+	if reread, err := Load(strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	} else {
+		checkArr(t, reread, "app", "hosts", []string{"a", "b"})
+	}
+}
@@ -0,0 +1,112 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrInheritanceCycle is returned when sections inherit from each other, as in [a : b] and [b : a]
+type ErrInheritanceCycle struct {
+	Sections []string // The sections in the cycle, starting and ending with the same one
+}
+
+func (e ErrInheritanceCycle) Error() string {
+	return fmt.Sprintf("INI sections inherit from each other: %s", strings.Join(e.Sections, " -> "))
+}
+
+// Split the inheritance clause from a section header, as in [prod : base, common].
+// A colon inside the quotes of a subsection name does not start a clause.
+func splitInheritance(name string) (section string, parents []string) {
+	quoted := false
+	for i, r := range name {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			for _, parent := range strings.Split(name[i+1:], ",") {
+				if parent = canonicalSection(strings.TrimSpace(parent)); parent != "" {
+					parents = append(parents, parent)
+				}
+			}
+			return strings.TrimSpace(name[:i]), parents
+		}
+	}
+	return name, nil
+}
+
+// Record that a section inherits from parents, after any it already inherits from
+func (s *section) inherit(parents []string) {
+	for _, parent := range parents {
+		found := false
+		for _, existing := range s.parents {
+			found = found || s.file.norm(existing) == s.file.norm(parent)
+		}
+		if !found {
+			s.parents = append(s.parents, parent)
+		}
+	}
+}
+
+// Returns the section followed by those it inherits keys from, in the order they are looked up in.
+// Parents are searched depth first in the order they are given, and ParseOptions.DefaultSection comes last.
+func (s *section) lineage() (lineage []*section) {
+	seen := make(map[string]bool)
+	var visit func(sect *section)
+	visit = func(sect *section) {
+		if seen[s.file.norm(sect.name)] {
+			return
+		}
+		seen[s.file.norm(sect.name)] = true
+		lineage = append(lineage, sect)
+		for _, parent := range sect.parents {
+			visit(s.file.lookup(parent))
+		}
+	}
+	visit(s)
+	if name := s.file.parseOptions.DefaultSection; name != "" {
+		visit(s.file.lookup(name))
+	}
+	return
+}
+
+// Returns an error if any section inherits from itself, directly or through other sections
+func (f *file) checkInheritance() error {
+	for _, name := range f.order {
+		if cycle := f.inheritanceCycle(f.lookup(name), nil); cycle != nil {
+			return ErrInheritanceCycle{Sections: cycle}
+		}
+	}
+	return nil
+}
+
+func (f *file) inheritanceCycle(sect *section, path []string) []string {
+	for i, prev := range path {
+		if f.norm(prev) == f.norm(sect.name) {
+			return append(path[i:len(path):len(path)], sect.name)
+		}
+	}
+	path = append(path[:len(path):len(path)], sect.name)
+	for _, parent := range sect.parents {
+		if cycle := f.inheritanceCycle(f.lookup(parent), path); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// Lists the sections that a section directly inherits from, as given in its header like [prod : base]
+func (f *file) Parents(section string) (value []string) {
+	sect := f.lookup(section)
+	value = make([]string, len(sect.parents))
+	copy(value, sect.parents)
+	return
+}
+
+// Lists every section that a section inherits keys from, in the order they are looked up in,
+// ending with ParseOptions.DefaultSection if it is set
+func (f *file) Ancestors(section string) (value []string) {
+	for _, sect := range f.lookup(section).lineage()[1:] {
+		value = append(value, sect.name)
+	}
+	return
+}
@@ -0,0 +1,69 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSectionInheritance(t *testing.T) {
+	src := `[base]
+host = localhost
+port = 80
+tags[] = web
+
+[common]
+port = 8080
+log = info
+
+[prod : base, common]
+host = example.com
+
+[canary : prod]
+log = debug
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{SectionInheritance: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "prod", "host", "example.com")
+	checkInt(t, file, "prod", "port", 80)
+	checkStr(t, file, "prod", "log", "info")
+	checkStr(t, file, "canary", "host", "example.com")
+	checkStr(t, file, "canary", "log", "debug")
+	checkArr(t, file, "canary", "tags", []string{"web"})
+	if sections := file.Sections(); !reflect.DeepEqual(sections, []string{"base", "common", "prod", "canary"}) {
+		t.Errorf("expected sections without their parents, got %v", sections)
+	}
+	if parents := file.Parents("prod"); !reflect.DeepEqual(parents, []string{"base", "common"}) {
+		t.Errorf("Parents(prod): got %v", parents)
+	}
+	if ancestors := file.Ancestors("canary"); !reflect.DeepEqual(ancestors, []string{"prod", "base", "common"}) {
+		t.Errorf("Ancestors(canary): got %v", ancestors)
+	}
+	expected := map[string]string{"host": "example.com", "port": "80", "log": "debug"}
+	if values := file.Values("canary"); !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected Values to include inherited keys, got %v", values)
+	}
+	file.Set("prod", "port", "443")
+	expectedOut := strings.Replace(src, "host = example.com\n", "host = example.com\nport = 443\n", 1)
+	if out := writeString(t, file); out != expectedOut {
+		t.Errorf("expected the headers to be kept; got: <<<%s<<< expected <<<%s<<<", out, expectedOut)
+	}
+
+	plain, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, plain, "prod : base, common", "host", "example.com")
+}
+
+func TestSectionInheritanceCycle(t *testing.T) {
+	src := "[a : b]\n[b : c]\n[c : a]\n"
+	_, err := LoadWithOptions(strings.NewReader(src), ParseOptions{SectionInheritance: true})
+	var cycleErr ErrInheritanceCycle
+	if !errors.As(err, &cycleErr) || !reflect.DeepEqual(cycleErr.Sections, []string{"a", "b", "c", "a"}) {
+		t.Errorf("expected a cycle, got %v", err)
+	}
+}
@@ -10,12 +10,25 @@ import (
 func NewFile() File {
 	file := file{}
 	file.sections = make(map[string]*section)
+	file.defaultBlock()
 	return &file
 }
 
+// NewFileWithOptions will create and initialise a File object that reads data with the given options
+func NewFileWithOptions(opts ParseOptions) File {
+	file := NewFile()
+	file.SetParseOptions(opts)
+	return file
+}
+
 // Load creates a File and populates it with data from a reader.
 func Load(in io.Reader) (File, error) {
-	file := NewFile()
+	return LoadWithOptions(in, ParseOptions{})
+}
+
+// LoadWithOptions creates a File and populates it with data from a reader, parsed with the given options.
+func LoadWithOptions(in io.Reader, opts ParseOptions) (File, error) {
+	file := NewFileWithOptions(opts)
 	_, err := file.ReadFrom(in)
 	return file, err
 }
@@ -23,13 +36,19 @@ func Load(in io.Reader) (File, error) {
 // LoadFile creates a File and populates it with data from a file on disk
 // This is a convenience helper since it is a very common use case
 func LoadFile(filename string) (file File, err error) {
-	file = nil
+	return LoadFileWithOptions(filename, ParseOptions{})
+}
+
+// LoadFileWithOptions creates a File and populates it with data from a file on disk, parsed with the given options.
+func LoadFileWithOptions(filename string, opts ParseOptions) (File, error) {
 	fh, err := os.Open(filename)
 	if err != nil {
-		return
+		return nil, err
 	}
 	defer fh.Close()
-	return Load(fh)
+	loaded := NewFileWithOptions(opts).(*file)
+	_, err = loaded.readFile(fh, filename)
+	return loaded, err
 }
 
 // Create a file and populate with data from an existing ini.Reader
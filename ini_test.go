@@ -362,14 +362,23 @@ func TestSyntaxError(t *testing.T) {
 	}
 }
 
+// The values held by each section of a file, without the document layout
+func sectionData(in File) map[string][2]interface{} {
+	data := make(map[string][2]interface{})
+	for name, sect := range in.(*file).sections {
+		data[name] = [2]interface{}{map[string]string(sect.stringValues), map[string][]string(sect.arrayValues)}
+	}
+	return data
+}
+
 func TestDefinedSectionBehaviour(t *testing.T) {
 	check := func(src string, expect File, t *testing.T) {
 		file, err := Load(strings.NewReader(src))
 		if err != nil {
 			t.Fatal(err)
 		}
-		if !reflect.DeepEqual(file, expect) {
-			t.Errorf("expected %v, got %v", expect, file)
+		if !reflect.DeepEqual(sectionData(file), sectionData(expect)) {
+			t.Errorf("expected %v, got %v", sectionData(expect), sectionData(file))
 		}
 	}
 	testFile := NewFile()
@@ -565,3 +574,669 @@ func TestCopy(t *testing.T) {
 		}
 	})
 }
+
+func writeString(t *testing.T, file File) string {
+	buf := new(bytes.Buffer)
+	if _, err := file.WriteTo(buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestRoundTrip(t *testing.T) {
+	check := func(src string, t *testing.T) {
+		file, err := Load(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out := writeString(t, file); out != src {
+			t.Errorf("Round trip changed the file; got: <<<%s<<< expected <<<%s<<<", out, src)
+		}
+	}
+	t.Run("comments", func(t *testing.T) {
+		check("# Header comment\n\n; About foo\nfoo = bar\n\n[a]\n  # indented\n  key  =   \"quoted\"   \n\n\n[b]\nx=1\n", t)
+	})
+	t.Run("noTrailingNewline", func(t *testing.T) {
+		check("[a]\nx = 1", t)
+	})
+	t.Run("crlf", func(t *testing.T) {
+		check("; comment\r\n[a]\r\nx = 1\r\narr[] = 2\r\n", t)
+	})
+	t.Run("repeatedSection", func(t *testing.T) {
+		check("[a]\nx = 1\n[b]\ny = 2\n[a]\nz = 3\n# trailing\n", t)
+	})
+}
+
+func TestEditInPlace(t *testing.T) {
+	src := `# Settings
+[server]
+; The host to listen on
+host = localhost   ; not an inline comment yet
+port=80
+
+[client]
+retries = 3
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("set", func(t *testing.T) {
+		file.SetInt("server", "port", 8080)
+		expected := strings.Replace(src, "port=80", "port=8080", 1)
+		if out := writeString(t, file); out != expected {
+			t.Errorf("Set changed more than one line; got: <<<%s<<< expected <<<%s<<<", out, expected)
+		}
+	})
+	t.Run("add", func(t *testing.T) {
+		file.Set("server", "timeout", "5")
+		file.SetArr("client", "hosts", []string{"a", "b"})
+		file.Set("extra", "key", "value")
+		expected := `# Settings
+[server]
+; The host to listen on
+host = localhost   ; not an inline comment yet
+port=8080
+timeout = 5
+
+[client]
+retries = 3
+hosts[] = a
+hosts[] = b
+
+[extra]
+key = value
+
+`
+		if out := writeString(t, file); out != expected {
+			t.Errorf("Incorrect output after adding values; got: <<<%s<<< expected <<<%s<<<", out, expected)
+		}
+	})
+	t.Run("remove", func(t *testing.T) {
+		file.Remove("server", "host")
+		file.RemoveSection("client")
+		file.SetArr("extra", "list", []string{"1"})
+		expected := `# Settings
+[server]
+port=8080
+timeout = 5
+
+[extra]
+key = value
+list[] = 1
+
+`
+		if out := writeString(t, file); out != expected {
+			t.Errorf("Incorrect output after removing values; got: <<<%s<<< expected <<<%s<<<", out, expected)
+		}
+	})
+}
+
+func TestOrder(t *testing.T) {
+	src := `top = 1
+[zeta]
+b = 2
+a[] = x
+a[] = y
+[alpha]
+z = 3
+y = 4
+[zeta]
+c = 5
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.Set("middle", "k", "v")
+	file.Get("missing", "k")
+
+	if sections := file.Sections(); !reflect.DeepEqual(sections, []string{"", "zeta", "alpha", "middle"}) {
+		t.Errorf("Sections(): expected source order, got %v", sections)
+	}
+	if keys := file.Keys("zeta"); !reflect.DeepEqual(keys, []string{"b", "a", "c"}) {
+		t.Errorf("Keys(zeta): expected source order, got %v", keys)
+	}
+	if keys := file.Keys("missing"); len(keys) != 0 {
+		t.Errorf("Keys(missing): expected no keys, got %v", keys)
+	}
+
+	t.Run("copy", func(t *testing.T) {
+		dest := NewFile()
+		file.Copy(dest)
+		if keys := dest.Keys("alpha"); !reflect.DeepEqual(keys, []string{"z", "y"}) {
+			t.Errorf("Keys(alpha) after copy: expected source order, got %v", keys)
+		}
+	})
+	t.Run("sorted", func(t *testing.T) {
+		file.SetWriteOptions(WriteOptions{Sort: true})
+		defer file.SetWriteOptions(WriteOptions{})
+		expected := `top = 1
+
+[alpha]
+y = 4
+z = 3
+
+[middle]
+k = v
+
+[zeta]
+b = 2
+c = 5
+a[] = x
+a[] = y
+
+`
+		if out := writeString(t, file); out != expected {
+			t.Errorf("Incorrect sorted output; got: <<<%s<<< expected <<<%s<<<", out, expected)
+		}
+	})
+}
+
+func TestContinuation(t *testing.T) {
+	src := `[jvm]
+opts = -Xmx1g \
+       -Xms512m \
+       -Dfoo=bar
+dsn = postgres://user@host/\
+  db?sslmode=disable
+path = C:\\
+after = 1
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "jvm", "opts", "-Xmx1g -Xms512m -Dfoo=bar")
+	checkStr(t, file, "jvm", "dsn", "postgres://user@host/db?sslmode=disable")
+	checkStr(t, file, "jvm", "path", `C:\\`)
+	checkStr(t, file, "jvm", "after", "1")
+	if out := writeString(t, file); out != src {
+		t.Errorf("Round trip changed the file; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	t.Run("syntaxError", func(t *testing.T) {
+		_, err := Load(strings.NewReader("[a]\nx = 1 \\\n  2\nwut \\\n  more\n"))
+		syntaxErr, ok := err.(ErrSyntax)
+		if !ok {
+			t.Fatalf("expected an error of type ErrSyntax, got %v", err)
+		}
+		if syntaxErr.Line != 4 {
+			t.Errorf("expected the error on line 4, got %d", syntaxErr.Line)
+		}
+	})
+}
+
+func TestIndentContinuation(t *testing.T) {
+	src := `[section]
+key = first
+    second
+      third
+other = value
+  # comment
+next = 1
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{IndentContinuation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "section", "key", "first\nsecond\nthird")
+	checkStr(t, file, "section", "other", "value")
+	checkStr(t, file, "section", "next", "1")
+	if out := writeString(t, file); out != src {
+		t.Errorf("Round trip changed the file; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	// Without the option, indentation does not matter
+	file, err = Load(strings.NewReader(src))
+	if err == nil {
+		t.Error("expected an error for the unassigned indented line")
+	}
+}
+
+func TestFold(t *testing.T) {
+	file := NewFile()
+	file.SetWriteOptions(WriteOptions{FoldWidth: 20})
+	file.Set("a", "opts", "-Xmx1g -Xms512m   -Dfoo=bar")
+	file.Set("a", "dsn", "postgres://user@host/db")
+	file.Set("a", "short", "fits")
+	expected := `[a]
+opts = -Xmx1g \
+    -Xms512m   \
+    -Dfoo=bar
+dsn = postgres://us\
+    er@host/db
+short = fits
+
+`
+	out := writeString(t, file)
+	if out != expected {
+		t.Errorf("Incorrect folded output; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	reread, err := Load(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, reread, "a", "opts", "-Xmx1g -Xms512m   -Dfoo=bar")
+	checkStr(t, reread, "a", "dsn", "postgres://user@host/db")
+}
+
+func TestEscapes(t *testing.T) {
+	src := `
+newline = "one\ntwo"
+tab = "a\tb"
+quote = "say \"hi\""
+backslash = "C:\\dir\\"
+unicode = "caf\u00e9"
+unknown = "C:\path"
+hash = "# not a comment"
+single = 'lit\eral\n'
+unclosed = "abc\"
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	check := func(key, expect string) {
+		checkStr(t, file, "", key, expect)
+	}
+	check("newline", "one\ntwo")
+	check("tab", "a\tb")
+	check("quote", `say "hi"`)
+	check("backslash", `C:\dir\`)
+	check("unicode", "café")
+	check("unknown", `C:\path`)
+	check("hash", "# not a comment")
+	check("single", `lit\eral\n`)
+	check("unclosed", `"abc\"`)
+}
+
+func TestWriteQuoted(t *testing.T) {
+	values := map[string]string{
+		"spaces":    "  padded  ",
+		"newline":   "one\ntwo\r\n",
+		"quoted":    `"already quoted"`,
+		"single":    `'x'`,
+		"backslash": `C:\dir\`,
+		"control":   "bell\a",
+		"plain":     `a "middle" quote`,
+	}
+	file := NewFile()
+	for key, value := range values {
+		file.Set("s", key, value)
+	}
+	out := writeString(t, file)
+	if !strings.Contains(out, "plain = a \"middle\" quote\n") {
+		t.Errorf("expected a value that needs no quotes to be written as it is, got <<<%s<<<", out)
+	}
+	if !strings.Contains(out, `newline = "one\ntwo\r\n"`) {
+		t.Errorf("expected newlines to be escaped, got <<<%s<<<", out)
+	}
+	reread, err := Load(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, value := range values {
+		checkStr(t, reread, "s", key, value)
+	}
+}
+
+func TestMultilineValues(t *testing.T) {
+	src := `[tls]
+cert = """
+-----BEGIN CERTIFICATE-----
+MIIB
+-----END CERTIFICATE-----
+""" ; trailing comment
+inline = """one "quoted" line"""
+query <<SQL
+SELECT *
+  FROM users;
+SQL
+template = <<EOF
+Hello, ${name}
+EOF
+after = 1
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{MultilineValues: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "tls", "cert", "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----\n")
+	checkStr(t, file, "tls", "inline", `one "quoted" line`)
+	checkStr(t, file, "tls", "query", "SELECT *\n  FROM users;\n")
+	checkStr(t, file, "tls", "template", "Hello, ${name}\n")
+	checkStr(t, file, "tls", "after", "1")
+	if out := writeString(t, file); out != src {
+		t.Errorf("Round trip changed the file; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	t.Run("unterminated", func(t *testing.T) {
+		_, err := LoadWithOptions(strings.NewReader("a = 1\nb = \"\"\"\nnever\nclosed\n"), ParseOptions{MultilineValues: true})
+		syntaxErr, ok := err.(ErrSyntax)
+		if !ok {
+			t.Fatalf("expected an error of type ErrSyntax, got %v", err)
+		}
+		if syntaxErr.Line != 2 {
+			t.Errorf("expected the error on line 2, got %d", syntaxErr.Line)
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		values := map[string]string{
+			"query":    "SELECT 1\nFROM dual",
+			"cert":     "line one\nline two\n",
+			"triple":   "has \"\"\" quotes\nEOF\n",
+			"noEnding": "ends in a quote\n\"",
+			"marker":   "<<EOF",
+		}
+		file.Set("tls", "query", values["query"])
+		file.Set("tls", "cert", values["cert"])
+		file.Set("tls", "triple", values["triple"])
+		file.Set("tls", "noEnding", values["noEnding"])
+		file.Set("tls", "marker", values["marker"])
+		out := writeString(t, file)
+		if !strings.Contains(out, "query = \"\"\"\nSELECT 1\nFROM dual\"\"\"\n") {
+			t.Errorf("expected a triple quoted value, got <<<%s<<<", out)
+		}
+		if !strings.Contains(out, "triple = <<EOF1\n") {
+			t.Errorf("expected a heredoc value, got <<<%s<<<", out)
+		}
+		reread, err := LoadWithOptions(strings.NewReader(out), ParseOptions{MultilineValues: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range values {
+			checkStr(t, reread, "tls", key, value)
+		}
+	})
+}
+
+func TestSubsections(t *testing.T) {
+	src := `[core]
+bare = false
+[remote "origin"]
+url = git@example.com:origin.git
+[remote  "fork \"two\""]
+url = git@example.com:fork.git
+[branch "main"]
+remote = origin
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subsections := file.Subsections("remote"); !reflect.DeepEqual(subsections, []string{"origin", `fork "two"`}) {
+		t.Errorf("Subsections(remote): got %v", subsections)
+	}
+	if subsections := file.Subsections("core"); len(subsections) != 0 {
+		t.Errorf("Subsections(core): expected none, got %v", subsections)
+	}
+	if value, _ := file.GetSub("remote", `fork "two"`, "url"); value != "git@example.com:fork.git" {
+		t.Errorf("GetSub: got %q", value)
+	}
+	checkStr(t, file, `remote "origin"`, "url", "git@example.com:origin.git")
+
+	file.SetSub("remote", "origin", "fetch", "+refs/heads/*:refs/remotes/origin/*")
+	file.SetSub("remote", `back\slash "quoted"`, "url", "x")
+	expected := src[:strings.Index(src, "[remote  ")] + "fetch = +refs/heads/*:refs/remotes/origin/*\n" + src[strings.Index(src, "[remote  "):] +
+		"\n[remote \"back\\\\slash \\\"quoted\\\"\"]\nurl = x\n\n"
+	out := writeString(t, file)
+	if out != expected {
+		t.Errorf("Incorrect output with subsections; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	reread, err := Load(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := reread.GetSub("remote", `back\slash "quoted"`, "url"); value != "x" {
+		t.Errorf("GetSub after writing: got %q", value)
+	}
+}
+
+func TestDuplicates(t *testing.T) {
+	src := `[db]
+host = primary
+port = 5432
+[cache]
+host = redis
+[db]
+host = replica
+`
+	load := func(opts ParseOptions) File {
+		file, err := LoadWithOptions(strings.NewReader(src), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	t.Run("lastWins", func(t *testing.T) {
+		file := load(ParseOptions{})
+		checkStr(t, file, "db", "host", "replica")
+		checkStr(t, file, "db", "port", "5432")
+	})
+	t.Run("firstWins", func(t *testing.T) {
+		file := load(ParseOptions{DuplicateKeys: DuplicateKeyFirstWins})
+		checkStr(t, file, "db", "host", "primary")
+		file.Set("db", "host", "changed")
+		expected := strings.Replace(src, "host = primary", "host = changed", 1)
+		if out := writeString(t, file); out != expected {
+			t.Errorf("expected Set to change the first value; got: <<<%s<<< expected <<<%s<<<", out, expected)
+		}
+	})
+	t.Run("collect", func(t *testing.T) {
+		file := load(ParseOptions{DuplicateKeys: DuplicateKeyCollect})
+		checkArr(t, file, "db", "host", []string{"primary", "replica"})
+		checkStr(t, file, "db", "host", "replica")
+		if _, ok := file.GetArr("db", "port"); ok {
+			t.Error("expected a key that is not repeated to have no array")
+		}
+		file.SetArr("db", "host", []string{"one"})
+		expected := strings.Replace(src, "host = primary", "host = one", 1)
+		expected = strings.Replace(expected, "host = replica\n", "", 1)
+		if out := writeString(t, file); out != expected {
+			t.Errorf("expected SetArr to keep the repeated form; got: <<<%s<<< expected <<<%s<<<", out, expected)
+		}
+	})
+	t.Run("keyError", func(t *testing.T) {
+		_, err := LoadWithOptions(strings.NewReader(src), ParseOptions{DuplicateKeys: DuplicateKeyError})
+		expected := ErrDuplicate{Section: "db", Key: "host", Line: 7, FirstLine: 2}
+		if err != expected {
+			t.Errorf("expected %v, got %v", expected, err)
+		}
+	})
+	t.Run("sectionError", func(t *testing.T) {
+		_, err := LoadWithOptions(strings.NewReader(src), ParseOptions{DuplicateSections: DuplicateSectionError})
+		expected := ErrDuplicate{Section: "db", Line: 6, FirstLine: 1}
+		if err != expected {
+			t.Errorf("expected %v, got %v", expected, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "line 6") {
+			t.Errorf("expected the error to give the line, got %q", err.Error())
+		}
+	})
+}
+
+func TestNormalizedLookup(t *testing.T) {
+	src := "[Server]\nHost-Name = example.com\nPort = 80\n"
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Normalize: Normalizers(FoldCase, FoldSeparators)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "server", "host_name", "example.com")
+	checkStr(t, file, "SERVER", "HOST.NAME", "example.com")
+	file.Set("server", "port", "8080")
+	file.Set("SERVER", "user", "admin")
+	file.Remove("server", "HOST_NAME")
+	if sections := file.Sections(); !reflect.DeepEqual(sections, []string{"Server"}) {
+		t.Errorf("expected the original spelling of the section, got %v", sections)
+	}
+	if values := file.Values("server"); !reflect.DeepEqual(values, map[string]string{"Port": "8080", "user": "admin"}) {
+		t.Errorf("expected values with the spelling they were first given, got %v", values)
+	}
+	expected := "[Server]\nPort = 8080\nuser = admin\n"
+	if out := writeString(t, file); out != expected {
+		t.Errorf("expected the original spelling to be written; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	file.RemoveSection("SERVER")
+	if len(file.Sections()) != 0 {
+		t.Errorf("expected RemoveSection to ignore case, got %v", file.Sections())
+	}
+
+	exact, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := exact.Get("server", "Port"); ok {
+		t.Error("expected lookups to be exact by default")
+	}
+}
+
+func TestDelimiters(t *testing.T) {
+	src := "[db]\nhost: example.com\nport 5432\nurl = http://example.com:80\nempty\t:\nlist[] : a\n"
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Delimiters: []string{"=", ":", " "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "db", "host", "example.com")
+	checkStr(t, file, "db", "port", "5432")
+	checkStr(t, file, "db", "url", "http://example.com:80")
+	checkStr(t, file, "db", "empty", "")
+	checkArr(t, file, "db", "list", []string{"a"})
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected an unchanged round trip; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	if _, err := LoadWithOptions(strings.NewReader("key: value\n"), ParseOptions{}); err == nil {
+		t.Error("expected a colon not to be a delimiter by default")
+	}
+
+	file, err = LoadWithOptions(strings.NewReader("[db]\nport 5432\n"), ParseOptions{Delimiters: []string{" "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.SetWriteOptions(WriteOptions{Delimiter: " "})
+	file.Set("db", "port", "")
+	file.Set("db", "user", "admin")
+	expected := "[db]\nport \"\"\nuser admin\n"
+	if out := writeString(t, file); out != expected {
+		t.Errorf("expected the write delimiter to be used; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	reread, err := LoadWithOptions(strings.NewReader(expected), ParseOptions{Delimiters: []string{" "}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, reread, "db", "port", "")
+	checkStr(t, reread, "db", "user", "admin")
+
+	for _, delimiters := range [][]string{{":", " "}, {":"}, {" ", "="}} {
+		options := ParseOptions{Delimiters: delimiters}
+		file, err = LoadWithOptions(strings.NewReader("[db]\n"), options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Set("db", "host", "z")
+		out := writeString(t, file)
+		reread, err := LoadWithOptions(strings.NewReader(out), options)
+		if err != nil {
+			t.Fatalf("%q: expected a new key to be read back, got %v from <<<%s<<<", delimiters, err, out)
+		}
+		checkStr(t, reread, "db", "host", "z")
+	}
+}
+
+func TestNoValue(t *testing.T) {
+	src := "[mysqld]\nskip-name-resolve\nquick ; flag\nsocket =\nport = 3306\n"
+	if _, err := Load(strings.NewReader(src)); err == nil {
+		t.Error("expected a key without a value to be an error by default")
+	}
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{AllowNoValue: true, InlineComments: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "mysqld", "quick", "")
+	if value, ok := file.GetBool("mysqld", "skip-name-resolve"); !value || !ok {
+		t.Errorf("expected a key without a value to be true, got %v %v", value, ok)
+	}
+	if value, ok := file.GetBool("mysqld", "socket"); value || !ok {
+		t.Errorf("expected an empty value to be false, got %v %v", value, ok)
+	}
+	if file.HasValue("mysqld", "quick") || !file.HasValue("mysqld", "socket") || file.HasValue("mysqld", "missing") {
+		t.Error("expected HasValue to tell keys without a value from empty values")
+	}
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected keys without values to be written bare; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+	file.Set("mysqld", "quick", "1")
+	expected := strings.Replace(src, "quick ; flag", "quick = 1 ; flag", 1)
+	if out := writeString(t, file); out != expected {
+		t.Errorf("expected a value to be added; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	if !file.HasValue("mysqld", "quick") {
+		t.Error("expected a key to have a value once set")
+	}
+}
+
+func TestValueInfo(t *testing.T) {
+	src := "[app]\nunset =\nempty = \"\"\nsingle = 'a b'\nplain = value\nflag\n"
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{AllowNoValue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]ValueInfo{
+		"unset":  {},
+		"empty":  {Raw: `""`, Quoted: true, Quote: '"'},
+		"single": {Raw: "'a b'", Quoted: true, Quote: '\''},
+		"plain":  {Raw: "value"},
+		"flag":   {NoValue: true},
+	}
+	for key, want := range expected {
+		if info, ok := file.GetInfo("app", key); !ok || info != want {
+			t.Errorf("expected %s to be %+v, got %+v %v", key, want, info, ok)
+		}
+	}
+	if info, _ := file.GetInfo("app", "unset"); !info.Empty() {
+		t.Error("expected `key =` to be empty")
+	}
+	if _, ok := file.GetInfo("app", "missing"); ok {
+		t.Error("expected no info for a missing key")
+	}
+	file.Set("app", "empty", "x")
+	file.Set("app", "single", "c d")
+	file.Set("app", "plain", "other")
+	requoted := "[app]\nunset =\nempty = \"x\"\nsingle = 'c d'\nplain = other\nflag\n"
+	if out := writeString(t, file); out != requoted {
+		t.Errorf("expected quoting to be kept; got: <<<%s<<< expected <<<%s<<<", out, requoted)
+	}
+}
+
+func TestDefaultSection(t *testing.T) {
+	src := "[DEFAULT]\ntimeout = 30\nurl = http://%(host)s/\n\n[web]\nhost = example.com\n\n[db]\ntimeout = 5\nhost = db.local\n"
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{DefaultSection: "DEFAULT", Interpolation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkInt(t, file, "web", "timeout", 30)
+	checkInt(t, file, "db", "timeout", 5)
+	checkStr(t, file, "web", "url", "http://example.com/")
+	checkStr(t, file, "db", "url", "http://db.local/")
+	expected := map[string]string{"timeout": "30", "url": "http://%(host)s/", "host": "example.com"}
+	if values := file.Values("web"); !reflect.DeepEqual(values, expected) {
+		t.Errorf("expected Values to include inherited keys, got %v", values)
+	}
+	file.Set("web", "timeout", "60")
+	checkInt(t, file, "web", "timeout", 60)
+	checkInt(t, file, "DEFAULT", "timeout", 30)
+	expectedOut := strings.Replace(src, "host = example.com\n", "host = example.com\ntimeout = 60\n", 1)
+	if out := writeString(t, file); out != expectedOut {
+		t.Errorf("expected inherited keys not to be written; got: <<<%s<<< expected <<<%s<<<", out, expectedOut)
+	}
+
+	plain, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain.Get("web", "timeout"); ok {
+		t.Error("expected no inheritance by default")
+	}
+}
@@ -13,8 +13,30 @@ type Getter interface {
 	GetBool(section, key string) (value bool, ok bool)
 	// Looks up a value for an array key in a section and returns that value, along with a boolean result similar to a map lookup.
 	GetArr(section, key string) (value []string, ok bool)
-	// Lists the sections in the file
+	// Looks up a value for a key in a section and splits it into a list at a separator, with CSV style quotes,
+	// along with a boolean result similar to a map lookup. A key that only has an array value returns that array.
+	GetList(section, key, sep string) (value []string, ok bool)
+	// Reports whether a key in a section has a value, which may be empty.
+	// This is false for a key that is missing or was read without a value, as allowed by ParseOptions.AllowNoValue.
+	HasValue(section, key string) bool
+	// Looks up a value for a key in a section without expanding references, along with a boolean result similar to a map lookup.
+	GetRaw(section, key string) (value string, ok bool)
+	// Looks up a value for a key in a section, expanding references to other keys such as ${key}, ${section:key} and %(key)s.
+	// The `ok` boolean is false if the key is missing, and an ErrReference is returned if a reference cannot be resolved.
+	Expand(section, key string) (value string, ok bool, err error)
+	// Checks that every environment variable required by a value in the file is set, when ParseOptions.ExpandEnv is.
+	// This returns an ErrEnv listing all of those that are not.
+	CheckEnv() error
+	// Lists the sections that a section directly inherits from, as given in its header like [prod : base]
+	Parents(section string) (value []string)
+	// Lists every section that a section inherits keys from, in the order they are looked up in
+	Ancestors(section string) (value []string)
+	// Returns how a key in a section is written, such as whether it is quoted, along with a boolean result similar to a map lookup.
+	GetInfo(section, key string) (info ValueInfo, ok bool)
+	// Lists the sections in the file, in the order they were read or created
 	Sections() (value []string)
+	// Lists the keys in a section, including array keys, in the order they were read or set
+	Keys(section string) (value []string)
 	// Lists the values in a section the file
 	Values(section string) (value map[string]string)
 
@@ -27,6 +49,36 @@ type Getter interface {
 	DisableEnvironmentVariableOverrides()
 }
 
+// A SubsectionGetter can look up git-config style subsections, such as [remote "origin"].
+// Subsections can also be used with the other methods of a File by naming them with SubsectionName.
+type SubsectionGetter interface {
+	// Lists the subsections of a section in the file, such as "origin" for [remote "origin"]
+	Subsections(section string) (value []string)
+	// Looks up a value for a key in a subsection and returns that value, along with a boolean result similar to a map lookup.
+	GetSub(section, subsection, key string) (value string, ok bool)
+}
+
+// A SubsectionSetter can set values in git-config style subsections
+type SubsectionSetter interface {
+	// Set the value for a key in a subsection, creating the subsection if it does not exist
+	SetSub(section, subsection, key, value string) (ok bool)
+}
+
+// A LocaleGetter can look up localized keys, such as Name[de] in a freedesktop.org desktop entry
+type LocaleGetter interface {
+	// Looks up a value for a key in a locale, such as "de_DE.UTF-8", falling back to less specific locales and then
+	// to the key without a locale, along with a boolean result similar to a map lookup.
+	GetLocale(section, key, locale string) (value string, ok bool)
+	// Lists the locales that a key is localized for in a section
+	Locales(section, key string) (value []string)
+}
+
+// A LocaleSetter can set localized keys, such as Name[de] in a freedesktop.org desktop entry
+type LocaleSetter interface {
+	// Set the value of a key for a locale. An empty locale sets the key itself.
+	SetLocale(section, key, locale, value string) (ok bool)
+}
+
 type Copier interface {
 	// Copy loaded data to a writer
 	Copy(Setter)
@@ -43,11 +95,56 @@ type Setter interface {
 	SetArr(section, key string, value []string) bool
 }
 
+// A MapGetter can look up maps, as written with `key[index] = value` in ParseOptions.DialectPHP
+type MapGetter interface {
+	// Looks up a map for a key in a section, along with a boolean result similar to a map lookup.
+	GetMap(section, key string) (value map[string]string, ok bool)
+}
+
+// A MapSetter is a Setter that can also hold maps, as read by ParseOptions.DialectPHP.
+// Copy passes maps on to a Setter that implements it.
+type MapSetter interface {
+	Setter
+	// Set a key in a section to a map of strings
+	SetMap(section, key string, value map[string]string) bool
+}
+
+// A Commenter is able to read and write the comments in a file.
+// Comments are given without their comment marker, and a comment of several lines is separated with newlines.
+// Setting an empty comment removes it.
+type Commenter interface {
+	// HeaderComment returns the comment at the top of the file, which is separated from what follows by a blank line
+	HeaderComment() string
+	// SetHeaderComment sets the comment at the top of the file
+	SetHeaderComment(comment string)
+	// SectionComment returns the comment lines directly above a section header
+	SectionComment(section string) string
+	// SetSectionComment sets the comment above a section header, creating the section if it does not exist.
+	// The `ok` boolean will be false for the default section, which has no header.
+	SetSectionComment(section, comment string) (ok bool)
+	// SectionInlineComment returns the comment following a section header on the same line
+	SectionInlineComment(section string) string
+	// SetSectionInlineComment sets the comment following a section header, creating the section if it does not exist.
+	// The `ok` boolean will be false for the default section, which has no header.
+	SetSectionInlineComment(section, comment string) (ok bool)
+	// KeyComment returns the comment lines directly above a key
+	KeyComment(section, key string) string
+	// SetKeyComment sets the comment above a key. The `ok` boolean will be false if the key does not exist.
+	SetKeyComment(section, key, comment string) (ok bool)
+	// KeyInlineComment returns the comment following a key and its value on the same line.
+	// Inline comments on keys are only read from a source when ParseOptions.InlineComments is set.
+	KeyInlineComment(section, key string) string
+	// SetKeyInlineComment sets the comment following a key and its value. The `ok` boolean will be false if the key does not exist.
+	SetKeyInlineComment(section, key, comment string) (ok bool)
+}
+
 // A Reader is able to load and extract data from an io.Reader
 type Reader interface {
 	io.ReaderFrom
 	Getter
 	Copier
+	// SetParseOptions sets the options used when data is next read
+	SetParseOptions(opts ParseOptions)
 }
 
 // A Writer is able to set and write data to an io.Writer
@@ -58,6 +155,8 @@ type Writer interface {
 	Remove(section, key string)
 	// RemoveSection removes a whole section from an ini file (OK if it does not exist)
 	RemoveSection(section string)
+	// SetWriteOptions sets the options used when writing the file out
+	SetWriteOptions(opts WriteOptions)
 }
 
 // A ReadWriter is able to load, get, modify and save data
@@ -86,4 +185,11 @@ type StreamReadWriter interface {
 
 type File interface {
 	StreamReadWriter
+	Commenter
+	SubsectionGetter
+	SubsectionSetter
+	LocaleGetter
+	LocaleSetter
+	MapGetter
+	MapSetter
 }
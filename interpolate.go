@@ -0,0 +1,171 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrReference is returned when a value refers to a key that is missing, or to itself through other keys
+type ErrReference struct {
+	Section   string
+	Key       string
+	Reference string   // The reference that could not be resolved, as written, e.g. ${db:host}
+	Chain     []string // For a cycle, the keys that lead back to the first, as [section] key
+}
+
+func (e ErrReference) Error() string {
+	if e.Chain != nil {
+		return fmt.Sprintf("cannot expand %q in section [%s]: reference %s is a cycle: %s",
+			e.Key, e.Section, e.Reference, strings.Join(e.Chain, " -> "))
+	}
+	return fmt.Sprintf("cannot expand %q in section [%s]: reference %s is to a missing key", e.Key, e.Section, e.Reference)
+}
+
+// Returns the name of a key for a chain of references
+func referenceName(section, key string) string {
+	return fmt.Sprintf("[%s] %s", section, key)
+}
+
+// An expander expands the references in values, collecting the environment variables that are required but not set
+type expander struct {
+	file    *file
+	keys    bool // References to other keys are expanded, rather than only environment variables
+	missing []MissingEnv
+}
+
+// Looks up a value for a key in a section and expands it.
+// The `ok` boolean is false if the key is missing, and an error is returned if a reference cannot be resolved.
+func (f *file) expand(section, key string, keys bool) (value string, ok bool, err error) {
+	e := &expander{file: f, keys: keys}
+	if value, ok, err = e.value(section, key, nil); err == nil && e.missing != nil {
+		return "", false, ErrEnv{Missing: e.missing}
+	}
+	return
+}
+
+func (e *expander) value(section, key string, chain []string) (value string, ok bool, err error) {
+	raw, ok := e.file.lookup(section).GetRaw(key)
+	if !ok {
+		return
+	}
+	escapes := false
+	if e.file.parseOptions.Dialect == DialectPHP {
+		// Expand the text as written, so that \$ keeps a $ from starting a reference
+		if text, quoted := e.file.lookup(section).phpEscaped(key); quoted {
+			raw, escapes = text, true
+		}
+	}
+	name := referenceName(section, key)
+	for i, prev := range chain {
+		if e.file.norm(prev) == e.file.norm(name) {
+			cycle := append(chain[i:len(chain):len(chain)], name)
+			return "", false, ErrReference{Section: section, Key: key, Chain: cycle}
+		}
+	}
+	value, err = e.text(section, key, raw, escapes, append(chain[:len(chain):len(chain)], name))
+	return value, err == nil, err
+}
+
+// Expand the references in the text of a key's value. With escapes, the text is in PHP's double quotes and its escapes
+// are interpreted as it is expanded.
+func (e *expander) text(section, key, raw string, escapes bool, chain []string) (string, error) {
+	expandEnv := e.file.expandsEnv()
+	var expanded strings.Builder
+	for i := 0; i < len(raw); i++ {
+		refSection, refKey, end := section, "", -1
+		switch {
+		case escapes && raw[i] == '\\' && i+1 < len(raw) && strings.IndexByte(`"\$`, raw[i+1]) >= 0:
+			expanded.WriteByte(raw[i+1])
+			i++
+			continue
+		case strings.HasPrefix(raw[i:], "$$") && (e.keys || expandEnv) && e.file.parseOptions.Dialect != DialectPHP,
+			strings.HasPrefix(raw[i:], "%%") && e.keys:
+			// An escaped marker stands for itself
+			expanded.WriteByte(raw[i])
+			i++
+			continue
+		case strings.HasPrefix(raw[i:], "${"):
+			if end = closingBrace(raw, i+2); end < 0 {
+				break
+			}
+			inner := raw[i+2 : end]
+			end++
+			if name, op, arg, isEnv := envReference(inner); isEnv && expandEnv &&
+				(op != "" || !e.keys || !e.file.hasKey(section, name)) {
+				value, err := e.env(section, key, name, op, arg, escapes, chain)
+				if err != nil {
+					return "", err
+				}
+				expanded.WriteString(value)
+				i = end - 1
+				continue
+			}
+			if e.keys {
+				refKey = inner
+				if sectionName, keyName, found := strings.Cut(refKey, ":"); found {
+					refSection, refKey = sectionName, keyName
+				}
+			}
+		case strings.HasPrefix(raw[i:], "%(") && e.keys:
+			if end = strings.Index(raw[i:], ")s"); end >= 0 {
+				refKey = raw[i+2 : i+end]
+				end += i + 2
+			}
+		}
+		if end < 0 || refKey == "" {
+			expanded.WriteByte(raw[i])
+			continue
+		}
+		refValue, found, err := e.value(refSection, refKey, chain)
+		if err != nil {
+			if refErr, isRef := err.(ErrReference); isRef && refErr.Reference == "" {
+				// Report the cycle against the reference that closes it
+				refErr.Section, refErr.Key, refErr.Reference = section, key, raw[i:end]
+				err = refErr
+			}
+			return "", err
+		}
+		if !found {
+			return "", ErrReference{Section: section, Key: key, Reference: raw[i:end]}
+		}
+		expanded.WriteString(refValue)
+		i = end - 1
+	}
+	return expanded.String(), nil
+}
+
+// Returns the position of the brace closing a reference whose contents start at start, allowing for nested references,
+// or -1 if it is not closed
+func closingBrace(raw string, start int) int {
+	depth := 0
+	for i := start; i < len(raw); i++ {
+		switch {
+		case strings.HasPrefix(raw[i:], "${"):
+			depth++
+			i++
+		case raw[i] == '}' && depth == 0:
+			return i
+		case raw[i] == '}':
+			depth--
+		}
+	}
+	return -1
+}
+
+// Whether a key is in a section, which decides whether ${name} refers to it or to an environment variable
+func (f *file) hasKey(section, key string) bool {
+	_, found := f.lookup(section).GetRaw(key)
+	return found
+}
+
+// Looks up a value for a key in a section, expanding references to other keys such as ${key}, ${section:key} and %(key)s.
+// The `ok` boolean is false if the key is missing, and an ErrReference is returned if a reference cannot be resolved.
+// References are expanded whether or not ParseOptions.Interpolation is set, and environment variables if ParseOptions.ExpandEnv is.
+func (f *file) Expand(section, key string) (value string, ok bool, err error) {
+	return f.expand(section, key, true)
+}
+
+// Looks up a value for a key in a section without expanding references, along with a boolean result similar to a map lookup.
+func (f *file) GetRaw(section, key string) (value string, ok bool) {
+	return f.lookup(section).GetRaw(key)
+}
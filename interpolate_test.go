@@ -0,0 +1,83 @@
+package ini
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInterpolation(t *testing.T) {
+	src := `root = /srv
+[paths]
+data = ${:root}/data
+logs = ${:root}/logs
+cache = %(data)s/cache
+price = $$5 and 100%%
+port = ${server:port}
+[server]
+host = example.com
+port = 8080
+url = http://${host}:${port}/
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Interpolation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "paths", "logs", "/srv/logs")
+	checkStr(t, file, "paths", "cache", "/srv/data/cache")
+	checkStr(t, file, "paths", "price", "$5 and 100%")
+	checkStr(t, file, "server", "url", "http://example.com:8080/")
+	checkInt(t, file, "paths", "port", 8080)
+	if raw, _ := file.GetRaw("server", "url"); raw != "http://${host}:${port}/" {
+		t.Errorf("expected the raw value, got %q", raw)
+	}
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected references to be written unexpanded; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	file.Set("", "data", "/var")
+	checkStr(t, file, "paths", "cache", "/srv/data/cache")
+	file.Set("paths", "data", "${:root}/lib")
+	checkStr(t, file, "paths", "cache", "/srv/lib/cache")
+
+	os.Setenv("INTERP_SERVER_PORT", "9090")
+	defer os.Unsetenv("INTERP_SERVER_PORT")
+	file.EnableEnvironmentVariableOverrides("INTERP")
+	checkStr(t, file, "server", "url", "http://example.com:9090/")
+	file.DisableEnvironmentVariableOverrides()
+
+	plain, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, plain, "server", "url", "http://${host}:${port}/")
+	if value, ok, err := plain.Expand("server", "url"); !ok || err != nil || value != "http://example.com:8080/" {
+		t.Errorf("expected Expand to work without the option, got %q %v %v", value, ok, err)
+	}
+}
+
+func TestInterpolationErrors(t *testing.T) {
+	src := "[a]\nx = ${y}\ny = ${b:z}\n[b]\nz = %(w)s\nw = ${a:x}\nmissing = ${nope}\n"
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Interpolation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := file.Get("a", "x"); ok {
+		t.Error("expected a cycle not to be resolved")
+	}
+	_, _, err = file.Expand("a", "x")
+	var refErr ErrReference
+	if !errors.As(err, &refErr) {
+		t.Fatalf("expected an ErrReference, got %v", err)
+	}
+	expected := []string{"[a] x", "[a] y", "[b] z", "[b] w", "[a] x"}
+	if !reflect.DeepEqual(refErr.Chain, expected) || refErr.Reference != "${a:x}" {
+		t.Errorf("expected the cycle %v, got %v from %s", expected, refErr.Chain, refErr.Reference)
+	}
+	_, _, err = file.Expand("b", "missing")
+	if !reflect.DeepEqual(err, ErrReference{Section: "b", Key: "missing", Reference: "${nope}"}) {
+		t.Errorf("expected a missing reference, got %v", err)
+	}
+}
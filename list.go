@@ -0,0 +1,252 @@
+package ini
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrMixedArray is returned when an array is written both as an inline list, as in key = [a, b], and with key[] = value
+type ErrMixedArray struct {
+	Section string
+	Key     string
+	Line    int // The line that used the second form
+}
+
+func (e ErrMixedArray) Error() string {
+	return fmt.Sprintf("INI array %q in section [%s] is written both as a list and with key[] on line %d", e.Key, e.Section, e.Line)
+}
+
+// Read an inline list starting at an offset in a line, as in `key = [a, "b c"]`, going on over further lines until the
+// list is closed. Elements are separated by commas, and quoted elements may hold commas and brackets.
+// The `isList` boolean is false if the value is not a list, in which case nothing has been read.
+func (p *parser) readList(l *line, text string, start int) (values []string, isList bool, err error) {
+	rest := strings.TrimLeftFunc(text[start:], unicode.IsSpace)
+	if !strings.HasPrefix(rest, "[") {
+		return nil, false, nil
+	}
+	valueStart := len(text) - len(rest)
+	raw, eol := text, l.eol
+	var element strings.Builder
+	var quote byte
+	written := false // The current element has more than whitespace in it
+	add := func() bool {
+		value := strings.TrimSpace(element.String())
+		element.Reset()
+		if value == "" && !written {
+			return false
+		}
+		values, written = append(values, trimWithQuotes(value)), false
+		return true
+	}
+	values = []string{}
+	for pos := valueStart + 1; ; pos++ {
+		if pos >= len(raw) {
+			next, nextEOL, ok := p.next()
+			if !ok {
+				return nil, true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+			}
+			// The line break is whitespace between elements
+			raw, eol = raw+eol+next, nextEOL
+			pos--
+			continue
+		}
+		c := raw[pos]
+		switch {
+		case quote != 0:
+			element.WriteByte(c)
+			if c == '\\' && quote == '"' && pos+1 < len(raw) {
+				element.WriteByte(raw[pos+1])
+				pos++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			element.WriteByte(c)
+			quote, written = c, true
+		case c == ',':
+			if !add() {
+				return nil, true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+			}
+		case c == ']':
+			// A comma may follow the last element
+			add()
+			trailing := raw[pos+1:]
+			if trimmed := strings.TrimSpace(trailing); trimmed != "" && p.file.commentPrefix(trimmed) == "" {
+				return nil, true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+			}
+			l.kind = listLine
+			l.prefix, l.value, l.suffix, l.eol = text[:valueStart], raw[valueStart:pos+1], trailing, eol
+			return values, true, nil
+		case c == '[':
+			return nil, true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+		default:
+			element.WriteByte(c)
+			written = written || !unicode.IsSpace(rune(c))
+		}
+	}
+}
+
+// Store a list read from the source. A list for a key replaces any earlier list for it.
+func (p *parser) storeList(l *line, values []string, lineNum int) error {
+	sect := p.file.section(p.section)
+	key := p.file.norm(l.key)
+	id := p.block.name + "\x00" + key
+	if _, found := sect.arrayValues[key]; found && !p.lists[id] {
+		return ErrMixedArray{Section: p.section, Key: l.key, Line: lineNum}
+	}
+	p.lists[id] = true
+	sect.track(l.key)
+	sect.arrayValues[key] = values
+	return nil
+}
+
+// The text to write for an inline list
+func (f *file) formatList(values []string) string {
+	elements := make([]string, len(values))
+	for i, value := range values {
+		elements[i] = value
+		if value == "" || f.needsQuotes(value) || strings.ContainsAny(value, ",[]") {
+			elements[i] = quoteValue(value)
+		}
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+func (f *file) newListLine(key string, values []string) *line {
+	return &line{kind: listLine, key: key, prefix: key + f.delimiter(), value: f.formatList(values), eol: f.lineEnding(), edited: true}
+}
+
+// Returns the blocks of the document with the lines of each array replaced by an inline list where the first of them
+// was, for WriteOptions.InlineLists. Arrays with values read from included files are left as they are.
+func (f *file) inlineBlocks() []*block {
+	written := make(map[string]bool)
+	blocks := make([]*block, len(f.blocks))
+	for i, b := range f.blocks {
+		inline := &block{name: b.name, header: b.header}
+		for _, l := range b.lines {
+			if l.kind != arrayLine {
+				inline.lines = append(inline.lines, l)
+				continue
+			}
+			sect := f.sections[b.name]
+			values := sect.arrayValues[f.norm(l.key)]
+			lines := f.keyLines(sect.name, l.key, arrayLine)
+			if sect.firstIndex(l.key) >= 0 {
+				lines = activeIndexed(lines)
+			}
+			id := b.name + "\x00" + f.norm(l.key)
+			switch {
+			case len(lines) != len(values):
+				inline.lines = append(inline.lines, l)
+			case !written[id]:
+				written[id] = true
+				list := f.newListLine(l.key, values)
+				list.comments, list.prefix = l.comments, indentOf(l)+list.prefix
+				inline.lines = append(inline.lines, list)
+			}
+		}
+		blocks[i] = inline
+	}
+	return blocks
+}
+
+// Split a value into a list at a separator, with CSV style quoting: an element in double quotes may hold the separator,
+// and "" within the quotes stands for a single quote. Whitespace around elements, but not inside quotes, is trimmed.
+func splitList(value, sep string) []string {
+	values := []string{}
+	if strings.TrimSpace(value) == "" {
+		return values
+	}
+	var current strings.Builder
+	quoted, wasQuoted := false, false
+	kept := 0 // The length of the element that came from inside quotes, which is not trimmed
+	add := func() {
+		element := current.String()
+		if wasQuoted {
+			element = element[:kept] + strings.TrimSpace(element[kept:])
+		} else {
+			element = strings.TrimSpace(element)
+		}
+		values = append(values, element)
+		current.Reset()
+		wasQuoted = false
+	}
+	for i := 0; i < len(value); i++ {
+		switch {
+		case quoted && strings.HasPrefix(value[i:], `""`):
+			current.WriteByte('"')
+			i++
+		case quoted && value[i] == '"':
+			quoted, kept = false, current.Len()
+		case !quoted && !wasQuoted && value[i] == '"' && strings.TrimSpace(current.String()) == "":
+			current.Reset()
+			quoted, wasQuoted = true, true
+		case !quoted && strings.HasPrefix(value[i:], sep):
+			add()
+			i += len(sep) - 1
+		default:
+			current.WriteByte(value[i])
+		}
+	}
+	add()
+	return values
+}
+
+// Whether a value as it is written starts with a quoted element of a list that closes before the end of the value, as in
+// `"a", "b"`, rather than being a single value in quotes. Both "" and backslash escapes are skipped over.
+func quotesElements(raw string) bool {
+	if quoteOf(raw) != '"' {
+		return false
+	}
+	for i := 1; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\', strings.HasPrefix(raw[i:], `""`):
+			i++
+		case raw[i] == '"':
+			return i < len(raw)-1
+		}
+	}
+	return false
+}
+
+// Looks up a value for a key in this section and splits it into a list at a separator, along with a boolean result
+// similar to a map lookup. A key that only has an array value returns that array.
+func (s *section) GetList(key, sep string) (value []string, ok bool) {
+	if sep == "" {
+		sep = ","
+	}
+	raw, found := s.Get(key)
+	if !found {
+		return s.GetArr(key)
+	}
+	for _, sect := range s.lineage() {
+		if sect.overridden(key) {
+			break
+		}
+		if _, own := sect.stringValues[s.file.norm(key)]; !own {
+			continue
+		}
+		if info, written := s.file.GetInfo(sect.name, key); written && quotesElements(info.Raw) {
+			// Get would take the quotes around the first and last elements as quotes around the whole value
+			raw = info.Raw
+			if s.file.parseOptions.Interpolation || s.file.expandsEnv() {
+				e := &expander{file: s.file, keys: s.file.parseOptions.Interpolation}
+				expanded, err := e.text(sect.name, key, info.Raw, false, []string{referenceName(sect.name, key)})
+				if err != nil || e.missing != nil {
+					return nil, false
+				}
+				raw = expanded
+			}
+		}
+		break
+	}
+	return splitList(raw, sep), true
+}
+
+// Looks up a value for a key in a section and splits it into a list at a separator, such as "," when sep is empty,
+// along with a boolean result similar to a map lookup. Elements may be quoted as in CSV, as in `a.com, "b,c.com"`,
+// and whitespace around them is trimmed. A key that only has an array value returns that array, as GetArr does.
+func (f *file) GetList(section, key, sep string) (value []string, ok bool) {
+	return f.lookup(section).GetList(key, sep)
+}
@@ -0,0 +1,150 @@
+package ini
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInlineLists(t *testing.T) {
+	src := `[app]
+hosts = [a.example.com, "b, c", 'd[1]', "", ]
+ports = [
+    80,
+    443,
+]
+empty = []
+name = plain
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{InlineLists: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkArr(t, file, "app", "hosts", []string{"a.example.com", "b, c", "d[1]", ""})
+	checkArr(t, file, "app", "ports", []string{"80", "443"})
+	checkArr(t, file, "app", "empty", []string{})
+	checkStr(t, file, "app", "name", "plain")
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected an unchanged round trip; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	file.SetArr("app", "ports", []string{"8080", "x]y"})
+	file.SetArr("app", "extra", []string{"one"})
+	expected := strings.Replace(src, "ports = [\n    80,\n    443,\n]\n", "ports = [8080, \"x]y\"]\n", 1)
+	expected = strings.Replace(expected, "name = plain\n", "name = plain\nextra[] = one\n", 1)
+	if out := writeString(t, file); out != expected {
+		t.Errorf("got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	reread, err := LoadWithOptions(strings.NewReader(expected), ParseOptions{InlineLists: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkArr(t, reread, "app", "ports", []string{"8080", "x]y"})
+
+	file.Set("app", "name", "[a, b]")
+	reread, err = LoadWithOptions(strings.NewReader(writeString(t, file)), ParseOptions{InlineLists: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, reread, "app", "name", "[a, b]")
+
+	plain, err := Load(strings.NewReader("[app]\nhosts = [a, b]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, plain, "app", "hosts", "[a, b]")
+}
+
+func TestInlineListErrors(t *testing.T) {
+	for src, expected := range map[string]error{
+		"[app]\nhosts[] = a\nhosts = [b]\n": ErrMixedArray{Section: "app", Key: "hosts", Line: 3},
+		"[app]\nhosts = [b]\nhosts[] = a\n": ErrMixedArray{Section: "app", Key: "hosts", Line: 3},
+		"[app]\nhosts = [a,, b]\n":          ErrSyntax{Line: 2, Source: "hosts = [a,, b]"},
+		"[app]\nhosts = [a] b\n":            ErrSyntax{Line: 2, Source: "hosts = [a] b"},
+		"[app]\nhosts = [a,\n  b\n":         ErrSyntax{Line: 2, Source: "hosts = [a,"},
+	} {
+		if _, err := LoadWithOptions(strings.NewReader(src), ParseOptions{InlineLists: true}); err != expected {
+			t.Errorf("%q: expected %v, got %v", src, expected, err)
+		}
+	}
+}
+
+func TestWriteInlineLists(t *testing.T) {
+	src := "[app]\n# Servers\nhosts[] = a\nhosts[] = b, c\nname = x\n"
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.SetWriteOptions(WriteOptions{InlineLists: true})
+	file.SetArr("app", "ports", []string{"80"})
+	expected := "[app]\n# Servers\nhosts = [a, \"b, c\"]\nname = x\nports = [80]\n"
+	if out := writeString(t, file); out != expected {
+		t.Errorf("got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	file.SetWriteOptions(WriteOptions{InlineLists: true, Sort: true})
+	expected = "[app]\nname = x\n# Servers\nhosts = [a, \"b, c\"]\nports = [80]\n\n"
+	if out := writeString(t, file); out != expected {
+		t.Errorf("sorted: got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+}
+
+func TestGetList(t *testing.T) {
+	src := `[cors]
+allowed_origins = a.com, "b,c.com" , d.com
+origins = "a.com", "b,c.com"
+quoted = " padded ", "say ""hi""", ,last
+hosts[] = x
+hosts[] = y
+paths = /usr/bin:/bin
+empty =
+dir = "C:\\dir"
+escaped = "a\"b"
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lists := []struct {
+		key, sep string
+		expected []string
+	}{
+		{"allowed_origins", "", []string{"a.com", "b,c.com", "d.com"}},
+		{"origins", ",", []string{"a.com", "b,c.com"}},
+		{"quoted", ",", []string{" padded ", `say "hi"`, "", "last"}},
+		{"hosts", ",", []string{"x", "y"}},
+		{"paths", ":", []string{"/usr/bin", "/bin"}},
+		{"empty", ",", []string{}},
+		{"dir", ",", []string{`C:\dir`}},
+		{"escaped", ",", []string{`a"b`}},
+	}
+	for _, list := range lists {
+		if value, ok := file.GetList("cors", list.key, list.sep); !ok || !reflect.DeepEqual(value, list.expected) {
+			t.Errorf("GetList(%q): expected %q, got %q %v", list.key, list.expected, value, ok)
+		}
+	}
+	if _, ok := file.GetList("cors", "missing", ","); ok {
+		t.Error("expected a missing key not to be found")
+	}
+	file.Set("cors", "set", `"a", "b,c"`)
+	if value, _ := file.GetList("cors", "set", ","); !reflect.DeepEqual(value, []string{"a", "b,c"}) {
+		t.Errorf("expected a value that was set to be split as it was given, got %q", value)
+	}
+
+	expanded, err := LoadWithOptions(strings.NewReader("[cors]\nhost = b.com\norigins = \"a.com\", \"${host}\"\n"),
+		ParseOptions{Interpolation: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := expanded.GetList("cors", "origins", ","); !reflect.DeepEqual(value, []string{"a.com", "b.com"}) {
+		t.Errorf("expected quoted elements to be expanded, got %q", value)
+	}
+
+	os.Setenv("LIST_CORS_HOSTS", `p, "q,r"`)
+	defer os.Unsetenv("LIST_CORS_HOSTS")
+	file.EnableEnvironmentVariableOverrides("LIST")
+	defer file.DisableEnvironmentVariableOverrides()
+	if value, _ := file.GetList("cors", "hosts", ","); !reflect.DeepEqual(value, []string{"p", "q,r"}) {
+		t.Errorf("expected one environment variable to supply the list, got %q", value)
+	}
+}
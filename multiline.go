@@ -0,0 +1,86 @@
+package ini
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var heredocStartRegex = regexp.MustCompile(`^<<([A-Za-z_][A-Za-z0-9_]*)\s*$`)
+
+// Read a triple quoted or heredoc value starting at an offset in a line.
+// The `isBlock` boolean is false if the value is not in either form, in which case nothing has been read.
+func (p *parser) readBlock(l *line, text string, start int) (value string, isBlock bool, err error) {
+	rest := strings.TrimLeftFunc(text[start:], unicode.IsSpace)
+	valueStart := len(text) - len(rest)
+	raw, eol := text, l.eol
+	if strings.HasPrefix(rest, `"""`) {
+		body := rest[3:]
+		for {
+			if end := strings.Index(body, `"""`); end >= 0 {
+				value, trailing := body[:end], body[end+3:]
+				if trimmed := strings.TrimSpace(trailing); trimmed != "" && p.file.commentPrefix(trimmed) == "" {
+					return "", true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+				}
+				// A newline straight after the opening quotes only serves to start the value on a line of its own
+				if strings.HasPrefix(value, "\r\n") {
+					value = value[2:]
+				} else if strings.HasPrefix(value, "\n") {
+					value = value[1:]
+				}
+				l.prefix, l.value, l.suffix, l.eol = text[:valueStart], raw[valueStart:len(raw)-len(trailing)], trailing, eol
+				return value, true, nil
+			}
+			next, nextEOL, ok := p.next()
+			if !ok {
+				return "", true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+			}
+			body += eol + next
+			raw, eol = raw+eol+next, nextEOL
+		}
+	}
+	if groups := heredocStartRegex.FindStringSubmatch(rest); groups != nil {
+		var lines strings.Builder
+		for {
+			next, nextEOL, ok := p.next()
+			if !ok {
+				return "", true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+			}
+			raw, eol = raw+eol+next, nextEOL
+			if strings.TrimSpace(next) == groups[1] {
+				break
+			}
+			lines.WriteString(next + nextEOL)
+		}
+		l.prefix, l.value, l.suffix, l.eol = text[:valueStart], raw[valueStart:], "", eol
+		return lines.String(), true, nil
+	}
+	return "", false, nil
+}
+
+// The text to write for a value containing newlines, in triple quotes where possible or otherwise as a heredoc.
+// The `ok` boolean is false if the value can be written in neither form.
+func (f *file) blockValue(value string) (block string, ok bool) {
+	if !strings.Contains(value, `"""`) && !strings.HasSuffix(value, `"`) {
+		return `"""` + f.lineEnding() + value + `"""`, true
+	}
+	if !strings.HasSuffix(value, "\n") {
+		// A heredoc always ends with a newline
+		return "", false
+	}
+	lines := strings.Split(value, "\n")
+	for i := 0; ; i++ {
+		tag := "EOF"
+		if i > 0 {
+			tag += strconv.Itoa(i)
+		}
+		clash := false
+		for _, l := range lines {
+			clash = clash || strings.TrimSpace(l) == tag
+		}
+		if !clash {
+			return "<<" + tag + f.lineEnding() + value + tag, true
+		}
+	}
+}
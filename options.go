@@ -0,0 +1,195 @@
+package ini
+
+import "strings"
+
+// ParseOptions control how INI data is read into a File
+type ParseOptions struct {
+	// IndentContinuation treats a line that is indented further than the key before it as another line of that key's value,
+	// in the style of Python's configparser. The lines of such a value are joined with newlines.
+	// A line ending in a backslash always continues onto the next line, whatever this is set to.
+	IndentContinuation bool
+	// CommentPrefixes are the markers that start a comment, such as ";", "#", "//" or "REM". The default is ";" and "#".
+	// A marker ending in a letter or digit must be followed by whitespace, so that REM does not match a key like REMOTE.
+	CommentPrefixes []string
+	// InlineComments strips comments that follow a value on the same line, as in `port = 8080 ; default`.
+	// A value starting with a quote may contain comment markers up to its closing quote.
+	InlineComments bool
+	// InlineCommentSpace only treats a marker as an inline comment when whitespace comes before it,
+	// so that values such as URLs with a #fragment are kept whole.
+	InlineCommentSpace bool
+	// MultilineValues allows values that span lines and are kept exactly as written, either in triple quotes
+	// (`key = """` ... `"""`) or as a heredoc (`key <<EOF` ... `EOF`). A newline directly after the opening
+	// triple quotes is not part of the value. Values containing newlines are written back in one of these forms.
+	MultilineValues bool
+	// InlineLists reads a value in brackets as an array for GetArr, as in `hosts = [a, "b, c", d]`. Elements that hold
+	// commas or brackets are quoted, and a list may span lines until its closing bracket. An array cannot be written
+	// both as a list and with `key[] = value`, which fails with an ErrMixedArray.
+	InlineLists bool
+	// DuplicateKeys decides what happens when a key appears more than once in a section of a source.
+	// The default is for the last value to win.
+	DuplicateKeys DuplicateKeyPolicy
+	// DuplicateSections decides what happens when a section header appears more than once in a source.
+	// The default is to merge the keys of each appearance.
+	DuplicateSections DuplicateSectionPolicy
+	// Normalize gives the form that section names and keys are compared in, so that for example FoldCase makes
+	// lookups case-insensitive. Sections and keys keep the spelling they were first read or set with.
+	// This should be set before any data is read into or set on the file. The default compares names exactly.
+	Normalize Normalizer
+	// Delimiters are the markers that may separate a key from its value, such as "=" or ":".
+	// The first delimiter in a line is used, so a value may contain any of them.
+	// A delimiter of whitespace, such as " ", allows `key value`; whitespace around another delimiter is
+	// not treated as one. The default is "=".
+	Delimiters []string
+	// AllowNoValue accepts keys on their own, as in the `skip-name-resolve` flags of a MySQL my.cnf.
+	// Such a key is read by Get as empty and by GetBool as true, and HasValue tells it apart from an empty value.
+	// It is written back without a delimiter until it is given a value.
+	AllowNoValue bool
+	// Includes reads the files named by `!include path` and `!includedir dir` lines, as in a MySQL my.cnf,
+	// and by `path` keys in an `[include]` section, as in git config. Relative paths are found from the directory
+	// of the including file when it was loaded with LoadFile. An included file starts in the default section.
+	// Its values are read into the file, but WriteTo only writes the directive. !includedir reads the files in
+	// a directory ending in .cnf, .conf or .ini, in name order.
+	Includes bool
+	// IncludeDepth is the deepest that includes may be nested. The default is 10.
+	IncludeDepth int
+	// IncludeRoot is a directory that included files must be in, if it is set
+	IncludeRoot string
+	// Interpolation expands references to other keys when values are looked up with Get, GetInt and GetBool.
+	// ${key} and %(key)s refer to a key in the same section and ${section:key} to a key in another section;
+	// $$ and %% stand for $ and %. Environment variable overrides apply to the keys that are referred to.
+	// Values are stored and written as they are read, and GetRaw and Values return them unexpanded.
+	Interpolation bool
+	// ExpandEnv expands references to environment variables when values are looked up, as in ${HOME}/data.
+	// ${NAME:-default} gives the default when the variable is unset or empty, and ${NAME:?message} requires it to be set;
+	// Get fails for a value requiring a variable that is not set, and Expand and CheckEnv return an ErrEnv listing them.
+	// With Interpolation as well, ${name} refers to a key in the same section if there is one.
+	ExpandEnv bool
+	// LookupEnv looks up environment variables for ExpandEnv. The default is os.LookupEnv.
+	LookupEnv func(name string) (value string, ok bool)
+	// DefaultSection names a section, such as the "DEFAULT" of Python's configparser, whose keys every other section
+	// inherits when they are looked up or listed with Values. Inherited keys are not written out with each section,
+	// and a key set in a section overrides the inherited one. No section is inherited from by default.
+	DefaultSection string
+	// SectionInheritance reads sections that inherit from others, as in [prod : base] or [prod : base, common].
+	// Keys that are not in a section are looked up in each parent in turn, and then in the parents' parents.
+	// Sections that inherit from each other fail with an ErrInheritanceCycle.
+	SectionInheritance bool
+	// Dialect reads and writes files with the syntax and semantics of a particular tool. The default is this package's own.
+	Dialect Dialect
+}
+
+// A Dialect adjusts how files are read and written to match a particular tool
+type Dialect int
+
+const (
+	// The syntax and semantics described by the other options
+	DialectDefault Dialect = iota
+	// systemd unit files. A repeated key builds a list for GetArr and an empty assignment resets it, while Get returns
+	// the last value. Quotes are part of values, a line ending in a backslash continues with a space, and keys are
+	// written as `Key=value`. A value set with a trailing backslash has it escaped as \\, and Set and SetArr
+	// fail for values with line breaks. Use LoadSystemdUnit to apply a unit's drop-ins.
+	DialectSystemd
+	// freedesktop.org desktop entry files. Keys may be localized, as in Name[de], for GetLocale.
+	// Values have the escapes \s, \n, \t, \r and \\, GetArr and SetArr use lists separated by ; with \; for
+	// a semicolon in a value, only # starts a comment and keys are written as `Key=value`.
+	DialectDesktop
+	// PHP's parse_ini_file. Keys of the form key[index] are entries in a map for GetMap, GetBool follows PHP's constants
+	// such as on, none and null unless the value is quoted, ${NAME} expands environment variables, only ; starts a
+	// comment and values that PHP would read differently are written in quotes. As in PHP, only \", \\ and \$ are
+	// escapes within double quotes, which may hold line breaks, and \${NAME} is not expanded while $$ is not an escape.
+	DialectPHP
+)
+
+// Whether references to environment variables are expanded in values, as in ${NAME}
+func (f *file) expandsEnv() bool {
+	return f.parseOptions.ExpandEnv || f.parseOptions.Dialect == DialectPHP
+}
+
+// Whether values are taken as they are written, without quotes or escapes being interpreted by the parser
+func (f *file) literalValues() bool {
+	return f.parseOptions.Dialect == DialectSystemd || f.parseOptions.Dialect == DialectDesktop
+}
+
+// A DuplicateKeyPolicy decides what happens when a key appears more than once in a section
+type DuplicateKeyPolicy int
+
+const (
+	// The value of the last appearance of a key is used
+	DuplicateKeyLastWins DuplicateKeyPolicy = iota
+	// The value of the first appearance of a key is used
+	DuplicateKeyFirstWins
+	// Reading fails with an ErrDuplicate
+	DuplicateKeyError
+	// Every value of a repeated key is collected into an array for GetArr, while Get returns the last value
+	DuplicateKeyCollect
+)
+
+// A DuplicateSectionPolicy decides what happens when a section header appears more than once
+type DuplicateSectionPolicy int
+
+const (
+	// The keys of every appearance of a section are merged into one section
+	DuplicateSectionMerge DuplicateSectionPolicy = iota
+	// Reading fails with an ErrDuplicate
+	DuplicateSectionError
+)
+
+// A Normalizer maps a section name or key to the form it is compared in.
+// It must map the empty name of the default section to itself.
+type Normalizer func(name string) string
+
+// FoldCase compares names without regard to case
+func FoldCase(name string) string {
+	return strings.ToLower(name)
+}
+
+// FoldSeparators compares names that differ only in their use of '-', '_' and '.' as equal
+func FoldSeparators(name string) string {
+	return separatorReplacer.Replace(name)
+}
+
+var separatorReplacer = strings.NewReplacer("-", "_", ".", "_")
+
+// Normalizers combines normalizers, which are applied in order
+func Normalizers(normalizers ...Normalizer) Normalizer {
+	return func(name string) string {
+		for _, normalize := range normalizers {
+			name = normalize(name)
+		}
+		return name
+	}
+}
+
+// SetParseOptions sets the options used when data is next read into the file
+func (f *file) SetParseOptions(opts ParseOptions) {
+	f.parseOptions = opts
+}
+
+// WriteOptions control how a File is written out by WriteTo and Read
+type WriteOptions struct {
+	// Sort writes sections and keys in alphabetical order, rather than in the order they were read or set.
+	// Comments directly above a section or key are kept with it; other comments and blank lines are dropped.
+	Sort bool
+	// CommentPrefix is the marker written before new comments. The default is "#", unless the parse options
+	// do not recognise it, in which case the first of their comment prefixes is used.
+	// Comments replacing existing ones keep the marker they had.
+	CommentPrefix string
+	// FoldWidth is the line length beyond which new and changed values are folded onto continuation lines
+	// ending in a backslash. Values are not folded if this is zero.
+	FoldWidth int
+	// Delimiter is written between new keys and their values, including any spacing, such as ": " or " ".
+	// It should be one of the delimiters the file will be read with. The default follows the first of
+	// ParseOptions.Delimiters, as in ": " or " ", and is " = " otherwise.
+	Delimiter string
+	// IndexedArrays writes arrays as `key[0] = value`, `key[1] = value` rather than `key[] = value`.
+	// Arrays that were read with indexes keep them either way.
+	IndexedArrays bool
+	// InlineLists writes arrays as inline lists, as in `key = [a, b]`, rather than as `key[] = value` lines.
+	// Arrays that were read as lists keep that form either way, and this takes precedence over IndexedArrays.
+	InlineLists bool
+}
+
+// SetWriteOptions sets the options used when the file is written out
+func (f *file) SetWriteOptions(opts WriteOptions) {
+	f.writeOptions = opts
+}
@@ -2,76 +2,530 @@ package ini
 
 import (
 	"bufio"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 var (
-	sectionRegex   = regexp.MustCompile(`^\[(.*)\]$`)
-	assignArrRegex = regexp.MustCompile(`^([^=\[\]]+)\[\][^=]*=(.*)$`)
-	assignRegex    = regexp.MustCompile(`^([^=]+)=(.*)$`)
-	quotesRegex    = regexp.MustCompile(`^(['"])(.*)(['"])$`)
+	heredocRegex = regexp.MustCompile(`^([^=\[\]<]+)<<[A-Za-z_][A-Za-z0-9_]*\s*$`)
+	mapKeyRegex  = regexp.MustCompile(`^([^\[\]]+)\[([^\[\]]+)\]$`)
+	indexRegex   = regexp.MustCompile(`^([^\[\]]+)\[([0-9]+)\]$`)
 )
 
+// Trim a value and remove a matching pair of surrounding quotes.
+// Escape sequences are interpreted in double quotes, while single quotes keep their contents literally.
 func trimWithQuotes(inputVal string) (ret string) {
 	ret = strings.TrimSpace(inputVal)
-	groups := quotesRegex.FindStringSubmatch(ret)
-	if groups != nil {
-		if groups[1] == groups[3] {
-			ret = groups[2]
-		}
+	if len(ret) < 2 {
+		return
+	}
+	inner := ret[1 : len(ret)-1]
+	switch {
+	case ret[0] == '"' && ret[len(ret)-1] == '"' && !continues(inner):
+		// A closing quote preceded by an unescaped backslash is part of the value, so the value is not quoted
+		ret = unescape(inner)
+	case ret[0] == '\'' && ret[len(ret)-1] == '\'':
+		ret = inner
 	}
 	return
 }
 
-func parseFile(in *bufio.Scanner, file *file) (bytes int64, err error) {
-	section := ""
-	lineNum := 0
-	bytes = -1
-	readLine := true
-	for readLine = in.Scan(); readLine; readLine = in.Scan() {
-		line := in.Text()
-		bytes++
-		bytes += int64(len(line))
-		lineNum++
-		line = strings.TrimSpace(line)
-		if len(line) == 0 {
-			// Skip blank lines
+// Interpret the escape sequences \n, \r, \t, \\, \" and \uXXXX. Any other backslash is kept as it is.
+func unescape(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var ret strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			ret.WriteByte(value[i])
 			continue
 		}
-		if line[0] == ';' || line[0] == '#' {
-			// Skip comments
-			continue
+		switch value[i+1] {
+		case 'n':
+			ret.WriteByte('\n')
+		case 'r':
+			ret.WriteByte('\r')
+		case 't':
+			ret.WriteByte('\t')
+		case '\\', '"':
+			ret.WriteByte(value[i+1])
+		case 'u':
+			code, err := strconv.ParseUint(value[min(i+2, len(value)):min(i+6, len(value))], 16, 32)
+			if err != nil || i+6 > len(value) {
+				ret.WriteString(value[i : i+2])
+				break
+			}
+			ret.WriteRune(rune(code))
+			i += 4
+		default:
+			ret.WriteString(value[i : i+2])
 		}
+		i++
+	}
+	return ret.String()
+}
 
-		if groups := assignArrRegex.FindStringSubmatch(line); groups != nil {
-			key, val := groups[1], groups[2]
-			key, val = strings.TrimSpace(key), trimWithQuotes(val)
-			curVal, ok := file.section(section).arrayValues[key]
-			if ok {
-				file.section(section).arrayValues[key] = append(curVal, val)
-			} else {
-				file.section(section).arrayValues[key] = make([]string, 1, 4)
-				file.section(section).arrayValues[key][0] = val
-			}
-		} else if groups := assignRegex.FindStringSubmatch(line); groups != nil {
-			key, val := groups[1], groups[2]
-			key, val = strings.TrimSpace(key), trimWithQuotes(val)
-			file.section(section).stringValues[key] = val
-		} else if groups := sectionRegex.FindStringSubmatch(line); groups != nil {
-			name := strings.TrimSpace(groups[1])
-			section = name
-			// Create the section if it does not exist
-			file.section(section)
+// Split the terminator from a line read from the source
+func splitEOL(text string) (content, eol string) {
+	if strings.HasSuffix(text, "\r\n") {
+		return text[:len(text)-2], "\r\n"
+	}
+	if strings.HasSuffix(text, "\n") {
+		return text[:len(text)-1], "\n"
+	}
+	return text, ""
+}
+
+// Split the text of a line at the start of a value into the prefix, the value and the suffix
+func splitValue(text string, start int) (prefix, value, suffix string) {
+	rest := strings.TrimLeftFunc(text[start:], unicode.IsSpace)
+	value = strings.TrimRightFunc(rest, unicode.IsSpace)
+	return text[:len(text)-len(rest)], value, rest[len(value):]
+}
+
+// Whether a line continues onto the next, which is when it ends with an unescaped backslash
+func continues(text string) bool {
+	count := len(text) - len(strings.TrimRight(text, "\\"))
+	return count%2 == 1
+}
+
+func indentWidth(text string) int {
+	return len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+}
+
+// Returns the delimiters between keys and values, and whether whitespace on its own is one of them
+func (f *file) delimiters() (delimiters []string, whitespace bool) {
+	if f.parseOptions.Delimiters == nil {
+		return []string{"="}, false
+	}
+	for _, delimiter := range f.parseOptions.Delimiters {
+		if strings.TrimSpace(delimiter) == "" {
+			whitespace = true
 		} else {
-			err = ErrSyntax{lineNum, line}
+			delimiters = append(delimiters, delimiter)
+		}
+	}
+	return
+}
+
+// Returns the end of a delimiter starting at pos in text, or -1 if there is none
+func delimiterAt(text string, pos int, delimiters []string) int {
+	for _, delimiter := range delimiters {
+		if strings.HasPrefix(text[pos:], delimiter) {
+			return pos + len(delimiter)
+		}
+	}
+	return -1
+}
+
+// Split an assignment at the first delimiter in the line. This returns the key and where the value starts;
+// ok is false if the line has no delimiter.
+// Whitespace around another delimiter is part of the layout rather than a delimiter itself.
+func (f *file) splitAssignment(text string) (key string, valueStart int, ok bool) {
+	delimiters, whitespace := f.delimiters()
+	indent := indentWidth(text)
+	for pos := 0; pos < len(text) && !ok; pos++ {
+		if end := delimiterAt(text, pos, delimiters); end >= 0 {
+			if pos == 0 {
+				return
+			}
+			key, valueStart, ok = text[:pos], end, true
+		} else if whitespace && pos > indent && unicode.IsSpace(rune(text[pos])) {
+			next := pos + indentWidth(text[pos:])
+			if next == len(text) {
+				return
+			}
+			if valueStart = delimiterAt(text, next, delimiters); valueStart < 0 {
+				valueStart = next
+			}
+			key, ok = text[:pos], true
+		}
+	}
+	key = strings.TrimSpace(key)
+	return
+}
+
+// Returns the kind of line that an assignment to a key is, along with the name of the key without any brackets.
+// A key such as `key[] = value` or `key[0] = value` is an array, and in the PHP dialect `key[index] = value` is
+// an entry in a map.
+func (f *file) keyForm(key string) (kind lineKind, name, index string) {
+	if f.parseOptions.Dialect == DialectDesktop {
+		// Brackets hold the locale of a key
+		return valueLine, key, ""
+	}
+	if name, found := strings.CutSuffix(key, "[]"); found && name != "" && !strings.ContainsAny(name, "[]") {
+		return arrayLine, strings.TrimSpace(name), ""
+	}
+	if groups := mapKeyRegex.FindStringSubmatch(key); groups != nil && f.parseOptions.Dialect == DialectPHP {
+		return mapLine, strings.TrimSpace(groups[1]), strings.TrimSpace(groups[2])
+	}
+	if groups := indexRegex.FindStringSubmatch(key); groups != nil {
+		return arrayLine, strings.TrimSpace(groups[1]), groups[2]
+	}
+	return valueLine, key, ""
+}
+
+// A parser holds the state for reading a source into the document of a file
+type parser struct {
+	file    *file
+	in      *bufio.Reader
+	block   *block  // The block that lines are currently added to
+	section string  // The name of the current section, as it is spelled in the source
+	pending []*line // Comment lines waiting for the section or value they describe
+	lineNum int
+	bytes   int64
+	err     error
+	eof     bool
+	held    []string // A line and terminator that was read ahead and put back
+
+	path     string        // The file being read, if it is known
+	chain    []IncludeSite // The include directives that led to this file
+	detached bool          // The file is included, so its lines are not part of the document
+
+	// The lines that sections and keys were first seen on, for finding duplicates
+	sections  map[string]int
+	keys      map[string]int
+	collected map[string]bool // Keys whose first value has been collected into an array
+	lists     map[string]bool // Keys whose arrays were read as inline lists
+}
+
+// Parse a source into a file. The path of the source is used to find included files, and may be empty.
+func parseFile(in *bufio.Reader, file *file, path string) (bytes int64, err error) {
+	p := &parser{
+		file:      file,
+		in:        in,
+		block:     file.defaultBlock(),
+		path:      path,
+		sections:  make(map[string]int),
+		keys:      make(map[string]int),
+		collected: make(map[string]bool),
+		lists:     make(map[string]bool),
+	}
+	if bytes, err = p.run(); err == nil {
+		err = file.placeIndexed()
+	}
+	if err == nil {
+		err = file.checkInheritance()
+	}
+	return
+}
+
+func (p *parser) run() (bytes int64, err error) {
+	defer p.flushComments()
+	for {
+		text, eol, ok := p.next()
+		if !ok {
+			return p.bytes, p.err
+		}
+		start := p.lineNum
+		if err = p.parseLine(text, eol); err != nil {
+			if syntaxErr, isSyntax := err.(ErrSyntax); isSyntax {
+				// Report the line that the entry started on, even if it went on over several lines
+				syntaxErr.Line = start
+				err = syntaxErr
+			}
+			// The erroneous line counts as read, but its terminator does not
+			return p.bytes - int64(len(eol)), err
+		}
+	}
+}
+
+// Read the next line from the source, split from its terminator
+func (p *parser) next() (text, eol string, ok bool) {
+	if p.held != nil {
+		text, eol, p.held = p.held[0], p.held[1], nil
+	} else {
+		if p.eof {
+			return
+		}
+		raw, err := p.in.ReadString('\n')
+		if err != nil {
+			p.eof = true
+			if err != io.EOF {
+				p.err = err
+			}
+		}
+		if raw == "" {
 			return
 		}
+		text, eol = splitEOL(raw)
+	}
+	p.lineNum++
+	p.bytes += int64(len(text) + len(eol))
+	if p.file.eol == "" {
+		p.file.eol = eol
+	}
+	return text, eol, true
+}
+
+// Put back a line that was read ahead, so that it is returned by the next call to next
+func (p *parser) unread(text, eol string) {
+	p.lineNum--
+	p.bytes -= int64(len(text) + len(eol))
+	p.held = []string{text, eol}
+}
+
+// Comments that are not directly above a section or value stand on their own
+func (p *parser) flushComments() {
+	p.block.lines = append(p.block.lines, p.pending...)
+	p.pending = nil
+}
+
+func (p *parser) parseLine(text, eol string) (err error) {
+	l := &line{prefix: text, eol: eol}
+	trimmed := strings.TrimSpace(text)
+	if len(trimmed) == 0 {
+		l.kind = blankLine
+		p.flushComments()
+		p.block.lines = append(p.block.lines, l)
+		return
+	}
+	if p.file.commentPrefix(trimmed) != "" {
+		l.kind = commentLine
+		p.pending = append(p.pending, l)
+		return
+	}
 
+	start := p.lineNum
+	valueStart := 0
+	if directive, path, ok := includeDirective(trimmed); ok && p.file.parseOptions.Includes {
+		l.kind = directiveLine
+		l.comments, p.pending = p.pending, nil
+		p.block.lines = append(p.block.lines, l)
+		return p.readInclude(directive, path, start)
+	} else if name, end, ok := p.sectionHeader(trimmed); ok {
+		// Anything after the closing bracket, such as an inline comment, is kept in the suffix
+		end += indentWidth(text)
+		l.kind = sectionLine
+		var parents []string
+		if p.file.parseOptions.SectionInheritance {
+			name, parents = splitInheritance(name)
+		}
+		l.key = canonicalSection(strings.TrimSpace(name))
+		l.prefix, l.suffix = text[:end], text[end:]
+		id := p.file.norm(l.key)
+		if first, seen := p.sections[id]; seen && p.file.parseOptions.DuplicateSections == DuplicateSectionError {
+			return ErrDuplicate{Section: l.key, Line: start, FirstLine: first}
+		} else if !seen {
+			p.sections[id] = start
+		}
+		l.comments, p.pending = p.pending, nil
+		p.block = &block{name: id, header: l}
+		if !p.detached {
+			p.file.blocks = append(p.file.blocks, p.block)
+		}
+		// Create the section if it does not exist, keeping the spelling it was first seen with
+		sect := p.file.section(l.key)
+		sect.inherit(parents)
+		p.section = sect.name
+		return
+	} else if key, end, ok := p.file.splitAssignment(text); ok {
+		l.kind, l.key, l.index = p.file.keyForm(key)
+		valueStart = end
+	} else if groups := heredocRegex.FindStringSubmatchIndex(text); groups != nil && p.file.parseOptions.MultilineValues {
+		// A heredoc may follow the key without a delimiter, as in `key <<EOF`
+		l.kind, valueStart, l.bare = valueLine, groups[3], true
+		l.key = strings.TrimSpace(text[groups[2]:groups[3]])
+	} else if p.file.parseOptions.AllowNoValue && trimmed[0] != '[' {
+		l.kind, l.bare = valueLine, true
+		p.noValue(l, text)
+		if err = p.store(l, "", start); err != nil {
+			return
+		}
+		l.comments, p.pending = p.pending, nil
+		p.block.lines = append(p.block.lines, l)
+		return
+	} else {
+		err = ErrSyntax{p.lineNum, trimmed}
+		return
+	}
+	if l.kind == valueLine && p.file.parseOptions.InlineLists {
+		if values, isList, err := p.readList(l, text, valueStart); isList {
+			if err == nil {
+				err = p.storeList(l, values, start)
+			}
+			if err == nil {
+				l.comments, p.pending = p.pending, nil
+				p.block.lines = append(p.block.lines, l)
+			}
+			return err
+		}
+	}
+	value, err := p.readValue(l, text, valueStart)
+	if err != nil {
+		return
 	}
-	if bytes < 0 {
-		bytes = 0
+	if err = p.store(l, value, start); err != nil {
+		return
+	}
+	l.comments, p.pending = p.pending, nil
+	p.block.lines = append(p.block.lines, l)
+	if p.file.parseOptions.Includes && l.kind == valueLine && p.isIncludeKey(l.key) {
+		err = p.include(p.resolve(value), start)
 	}
-	err = in.Err()
 	return
 }
+
+// Store a value read from the source, following the policy for keys that are repeated
+func (p *parser) store(l *line, value string, lineNum int) error {
+	sect := p.file.section(p.section)
+	sect.track(l.key)
+	key := p.file.norm(l.key)
+	if l.kind == arrayLine && p.lists[p.block.name+"\x00"+key] {
+		return ErrMixedArray{Section: p.section, Key: l.key, Line: lineNum}
+	}
+	if l.kind == arrayLine && l.index != "" {
+		return p.storeIndexed(sect, l, value, lineNum)
+	}
+	if l.kind == arrayLine {
+		if sect.indexed[key] != nil {
+			// An array written with indexes cannot also be appended to
+			return ErrSyntax{lineNum, strings.TrimSpace(l.prefix)}
+		}
+		sect.arrayValues[key] = append(sect.arrayValues[key], value)
+		return nil
+	}
+	if l.kind == mapLine {
+		if sect.mapValues == nil {
+			sect.mapValues = make(map[string]map[string]string)
+		}
+		if sect.mapValues[key] == nil {
+			sect.mapValues[key] = make(map[string]string)
+		}
+		sect.mapValues[key][l.index] = value
+		return nil
+	}
+	if p.file.parseOptions.Dialect == DialectSystemd {
+		sect.arrayValues[key] = systemdList(sect.arrayValues[key], value)
+		sect.stringValues[key] = value
+		return nil
+	}
+	id := p.block.name + "\x00" + key
+	if first, seen := p.keys[id]; !seen {
+		p.keys[id] = lineNum
+	} else {
+		switch p.file.parseOptions.DuplicateKeys {
+		case DuplicateKeyError:
+			return ErrDuplicate{Section: p.section, Key: l.key, Line: lineNum, FirstLine: first}
+		case DuplicateKeyFirstWins:
+			return nil
+		case DuplicateKeyCollect:
+			if !p.collected[id] {
+				p.collected[id] = true
+				sect.arrayValues[key] = append(sect.arrayValues[key], sect.stringValues[key])
+			}
+			sect.arrayValues[key] = append(sect.arrayValues[key], value)
+		}
+	}
+	sect.stringValues[key] = value
+	if l.bare && l.value == "" {
+		if sect.noValue == nil {
+			sect.noValue = make(map[string]bool)
+		}
+		sect.noValue[key] = true
+	} else {
+		delete(sect.noValue, key)
+	}
+	return nil
+}
+
+// Read a key that has no value, which may be followed by an inline comment
+func (p *parser) noValue(l *line, text string) {
+	end := len(text)
+	if p.file.parseOptions.InlineComments {
+		if pos := p.file.inlineCommentStart(text); pos >= 0 {
+			end = pos
+		}
+	}
+	l.prefix = strings.TrimRightFunc(text[:end], unicode.IsSpace)
+	l.suffix = text[len(l.prefix):]
+	l.key = strings.TrimSpace(l.prefix)
+}
+
+// Match a section header, which may be followed by a comment. This returns the name and the end of the header.
+// Where there is more than one closing bracket, the name runs to the last one that the rest of the line allows.
+func (p *parser) sectionHeader(trimmed string) (name string, end int, ok bool) {
+	if trimmed[0] != '[' {
+		return
+	}
+	for end = len(trimmed) - 1; end > 0; end-- {
+		if trimmed[end] != ']' {
+			continue
+		}
+		if rest := strings.TrimSpace(trimmed[end+1:]); rest == "" || p.file.commentPrefix(rest) != "" {
+			return trimmed[1:end], end + 1, true
+		}
+	}
+	return "", 0, false
+}
+
+// Read a value starting at an offset in a line, along with any lines that it continues onto.
+// The text of every line read is kept in the document line, and the value is returned with continuations joined.
+func (p *parser) readValue(l *line, text string, start int) (value string, err error) {
+	if p.file.parseOptions.MultilineValues {
+		if value, isBlock, err := p.readBlock(l, text, start); isBlock {
+			return value, err
+		}
+	}
+	if p.file.parseOptions.Dialect == DialectPHP {
+		if value, isQuoted, err := p.readPHPQuoted(l, text, start); isQuoted {
+			return value, err
+		}
+	}
+	raw, eol := text, l.eol
+	value = text[start:]
+	for cur := text; ; {
+		if continues(cur) && p.file.parseOptions.Dialect != DialectDesktop {
+			// The backslash is dropped, and so is the indentation of the next line
+			value = value[:len(value)-1]
+			if p.file.parseOptions.Dialect == DialectSystemd {
+				value = strings.TrimRightFunc(value, unicode.IsSpace) + " "
+			}
+			next, nextEOL, ok := p.next()
+			if !ok {
+				break
+			}
+			raw, eol, cur = raw+eol+next, nextEOL, next
+			value += strings.TrimLeftFunc(next, unicode.IsSpace)
+			continue
+		}
+		if p.file.parseOptions.IndentContinuation {
+			next, nextEOL, ok := p.next()
+			if !ok {
+				break
+			}
+			if nextTrimmed := strings.TrimSpace(next); nextTrimmed != "" && p.file.commentPrefix(nextTrimmed) == "" &&
+				indentWidth(next) > indentWidth(text) {
+				// A line indented further than the key is another line of the value
+				raw, eol, cur = raw+eol+next, nextEOL, next
+				value = strings.TrimSpace(value) + "\n" + strings.TrimLeftFunc(next, unicode.IsSpace)
+				continue
+			}
+			p.unread(next, nextEOL)
+		}
+		break
+	}
+	comment := ""
+	if p.file.parseOptions.InlineComments {
+		// A comment is only recognised on the last line of the value, and runs to its end
+		value = strings.TrimLeftFunc(value, unicode.IsSpace)
+		lastLine := strings.LastIndex(value, "\n") + 1
+		if pos := p.file.inlineCommentStart(value); pos >= lastLine && strings.HasSuffix(raw, value[pos:]) {
+			value, comment = value[:pos], value[pos:]
+		}
+	}
+	l.prefix, l.value, l.suffix = splitValue(raw[:len(raw)-len(comment)], start)
+	l.suffix += comment
+	l.eol = eol
+	if p.file.literalValues() {
+		return strings.TrimSpace(value), nil
+	}
+	if p.file.parseOptions.Dialect == DialectPHP {
+		return phpUnquote(strings.TrimSpace(value)), nil
+	}
+	return trimWithQuotes(value), nil
+}
@@ -0,0 +1,181 @@
+package ini
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Comments in PHP's INI files start with ; only
+var phpCommentPrefixes = []string{";"}
+
+// The characters that PHP does not accept, or reads as something else, in a value that is not quoted
+const phpSpecialChars = "?{}|&~![()^\"=;$"
+
+// Quote a value the way PHP reads it back: within double quotes only \", \\ and \$ are escapes, and line breaks are
+// written as they are. A $ is left alone, so that ${NAME} is still expanded.
+func phpQuote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// Remove a matching pair of surrounding quotes from a trimmed value, interpreting PHP's escapes in double quotes
+func phpUnquote(value string) string {
+	switch quoteOf(value) {
+	case '"':
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\$`, "$").Replace(value[1 : len(value)-1])
+	case '\'':
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Read a value in double quotes, which in PHP may go on over several lines until the closing quote.
+// The `isQuoted` boolean is false if the value does not start with a quote, in which case nothing has been read.
+func (p *parser) readPHPQuoted(l *line, text string, start int) (value string, isQuoted bool, err error) {
+	rest := strings.TrimLeftFunc(text[start:], unicode.IsSpace)
+	if !strings.HasPrefix(rest, `"`) {
+		return "", false, nil
+	}
+	valueStart := len(text) - len(rest)
+	raw, eol := text, l.eol
+	for pos := valueStart + 1; ; pos++ {
+		if pos >= len(raw) {
+			next, nextEOL, ok := p.next()
+			if !ok {
+				return "", true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+			}
+			raw, eol = raw+eol+next, nextEOL
+			pos--
+			continue
+		}
+		switch raw[pos] {
+		case '\\':
+			pos++
+		case '"':
+			trailing := raw[pos+1:]
+			if trimmed := strings.TrimSpace(trailing); trimmed != "" && p.file.commentPrefix(trimmed) == "" {
+				return "", true, ErrSyntax{p.lineNum, strings.TrimSpace(text)}
+			}
+			l.prefix, l.value, l.suffix, l.eol = text[:valueStart], raw[valueStart:pos+1], trailing, eol
+			return phpUnquote(l.value), true, nil
+		}
+	}
+}
+
+// Interpret a value as a boolean the way PHP does. PHP's constants such as on, yes and none are recognised unless
+// the value is quoted, and any other value is true unless it is empty or "0".
+func phpBool(value string, quoted bool) bool {
+	if !quoted {
+		switch strings.ToLower(value) {
+		case "true", "on", "yes":
+			return true
+		case "false", "off", "no", "none", "null":
+			return false
+		}
+	}
+	return value != "" && value != "0"
+}
+
+// Whether a value read by GetBool in the PHP dialect was written in quotes, which keeps PHP's constants as strings
+func (s *section) quoted(key string) bool {
+	for _, sect := range s.lineage() {
+		if _, found := sect.stringValues[s.file.norm(key)]; found {
+			info, _ := s.file.GetInfo(sect.name, key)
+			return info.Quoted
+		}
+	}
+	return false
+}
+
+// Returns the text between the double quotes of a value as it is written, with its escapes, so that references can be
+// expanded before they are interpreted. The `ok` boolean is false if the value in effect is not in double quotes.
+func (s *section) phpEscaped(key string) (text string, ok bool) {
+	for _, sect := range s.lineage() {
+		if sect.overridden(key) {
+			return
+		}
+		if _, found := sect.stringValues[s.file.norm(key)]; found {
+			if info, written := s.file.GetInfo(sect.name, key); written && info.Quote == '"' {
+				return info.Raw[1 : len(info.Raw)-1], true
+			}
+			return
+		}
+	}
+	return
+}
+
+// Looks up a map for a key in this section, as written with PHP's `key[index] = value`
+func (s *section) GetMap(key string) (value map[string]string, ok bool) {
+	for _, sect := range s.lineage() {
+		var entries map[string]string
+		if entries, ok = sect.mapValues[s.file.norm(key)]; ok {
+			value = make(map[string]string, len(entries))
+			for index, entry := range entries {
+				value[index] = entry
+			}
+			return
+		}
+	}
+	return
+}
+
+// Set a key in this section to a map, replacing any entries it had
+func (s *section) SetMap(key string, value map[string]string) (ok bool) {
+	s.track(key)
+	if s.mapValues == nil {
+		s.mapValues = make(map[string]map[string]string)
+	}
+	entries := make(map[string]string, len(value))
+	for index, entry := range value {
+		entries[index] = entry
+	}
+	s.mapValues[s.file.norm(key)] = entries
+	s.file.setMapLines(s.name, key, value)
+	return true
+}
+
+// Looks up a map for a key in a section, as written with PHP's `key[index] = value`, along with a boolean result similar to a map lookup.
+func (f *file) GetMap(section, key string) (value map[string]string, ok bool) {
+	return f.lookup(section).GetMap(key)
+}
+
+// Set a key in a section to a map, which is written as `key[index] = value` for each entry
+func (f *file) SetMap(section, key string, value map[string]string) (ok bool) {
+	return f.section(section).SetMap(key, value)
+}
+
+// Record a map value in the document, updating the lines of entries that are kept and adding new entries in order
+func (f *file) setMapLines(section, key string, value map[string]string) {
+	var prev *line
+	for _, l := range f.keyLines(section, key, mapLine) {
+		if entry, found := value[l.index]; found {
+			f.setValue(l, entry)
+			prev = l
+		} else {
+			f.removeLine(l)
+		}
+	}
+	indexes := make([]string, 0, len(value))
+	for index := range value {
+		indexes = append(indexes, index)
+	}
+	sort.Strings(indexes)
+	existing := f.keyLines(section, key, mapLine)
+	for _, index := range indexes {
+		found := false
+		for _, l := range existing {
+			found = found || l.index == index
+		}
+		if found {
+			continue
+		}
+		l := f.newValueLine(mapLine, key+"["+index+"]", value[index])
+		l.key, l.index = key, index
+		if prev == nil {
+			f.lastBlock(section).insert(l)
+		} else {
+			f.insertAfter(prev, l)
+		}
+		prev = l
+	}
+}
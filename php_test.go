@@ -0,0 +1,99 @@
+package ini
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPHP(t *testing.T) {
+	src := `; PHP settings
+[database]
+db[host] = localhost
+db[port] = 3306
+users[] = alice
+users[] = bob
+[flags]
+a = on
+b = none
+c = "off"
+d = null
+e = 0
+f = 2
+g = "1"
+path = "${PHP_TEST_HOME}/data"
+`
+	os.Setenv("PHP_TEST_HOME", "/home/php")
+	defer os.Unsetenv("PHP_TEST_HOME")
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Dialect: DialectPHP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db, ok := file.GetMap("database", "db"); !ok || !reflect.DeepEqual(db, map[string]string{"host": "localhost", "port": "3306"}) {
+		t.Errorf("GetMap: got %v %v", db, ok)
+	}
+	checkArr(t, file, "database", "users", []string{"alice", "bob"})
+	bools := map[string]bool{"a": true, "b": false, "c": true, "d": false, "e": false, "f": true, "g": true}
+	for key, expected := range bools {
+		if value, ok := file.GetBool("flags", key); !ok || value != expected {
+			t.Errorf("GetBool(%q): expected %v, got %v %v", key, expected, value, ok)
+		}
+	}
+	checkStr(t, file, "flags", "path", "/home/php/data")
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected an unchanged round trip; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	copied := NewFile()
+	file.Copy(copied)
+	if db, _ := copied.GetMap("database", "db"); !reflect.DeepEqual(db, map[string]string{"host": "localhost", "port": "3306"}) {
+		t.Errorf("expected Copy to pass maps to a MapSetter, got %v", db)
+	}
+
+	file.SetMap("database", "db", map[string]string{"host": "db.example.com", "user": "app"})
+	file.Set("flags", "query", "a=b")
+	expected := strings.NewReplacer(
+		"db[host] = localhost\ndb[port] = 3306\n", "db[host] = db.example.com\ndb[user] = app\n",
+		"path = \"${PHP_TEST_HOME}/data\"\n", "path = \"${PHP_TEST_HOME}/data\"\nquery = \"a=b\"\n",
+	).Replace(src)
+	if out := writeString(t, file); out != expected {
+		t.Errorf("got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+
+	file.Set("flags", "multi", "x\ny")
+	file.Set("flags", "windows", `C:\Temp "new"`)
+	out := writeString(t, file)
+	if !strings.Contains(out, "multi = \"x\ny\"\n") || !strings.Contains(out, `windows = "C:\\Temp \"new\""`) {
+		t.Errorf("expected values to be written with PHP's escapes; got: <<<%s<<<", out)
+	}
+	reread, err := LoadWithOptions(strings.NewReader(out), ParseOptions{Dialect: DialectPHP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, reread, "flags", "multi", "x\ny")
+	checkStr(t, reread, "flags", "windows", `C:\Temp "new"`)
+	checkStr(t, reread, "flags", "query", "a=b")
+	literal, err := LoadWithOptions(strings.NewReader(`k = "a\nb \"c\" \$d"`+"\n"), ParseOptions{Dialect: DialectPHP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, literal, "", "k", `a\nb "c" $d`)
+	env := func(name string) (string, bool) { return "x", name == "PHPX" }
+	escaped, err := LoadWithOptions(strings.NewReader(`a = "\${PHPX}"`+"\n"+`b = "$$5"`+"\n"+`c = "\\${PHPX}"`+"\n"),
+		ParseOptions{Dialect: DialectPHP, LookupEnv: env})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, escaped, "", "a", "${PHPX}")
+	checkStr(t, escaped, "", "b", "$$5")
+	checkStr(t, escaped, "", "c", `\x`)
+
+	file.Remove("database", "db")
+	if _, ok := file.GetMap("database", "db"); ok {
+		t.Error("expected the map to be removed")
+	}
+	if out := writeString(t, file); strings.Contains(out, "db[") {
+		t.Errorf("expected the map lines to be removed; got: <<<%s<<<", out)
+	}
+}
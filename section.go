@@ -13,6 +13,11 @@ type section struct {
 	name         string
 	stringValues stringSection
 	arrayValues  arraySection
+	mapValues    map[string]map[string]string // Entries of PHP style maps, as in `key[index] = value`
+	indexed      map[string]map[int]string    // Values of arrays written with indexes, as in `key[0] = value`
+	keys         []string                     // Keys in the order they were read or set
+	noValue      map[string]bool              // Keys that were read without a value, see ParseOptions.AllowNoValue
+	parents      []string                     // Sections this section inherits from, see ParseOptions.SectionInheritance
 }
 
 // All ini settings for a section except arrays are stored in this
@@ -45,13 +50,38 @@ func (s *section) envVarNameForKey(key string) string {
 }
 
 // Looks up a value for a key in a section and returns that value, along with a boolean result similar to a map lookup.
+// References are expanded if ParseOptions.Interpolation or ExpandEnv is set, and ok is false if they cannot be.
 func (s *section) Get(key string) (value string, ok bool) {
-	if s.file != nil && s.file.environmentOverrideEnabled {
-		if envValue, varIsSet := os.LookupEnv(s.envVarNameForKey(key)); varIsSet {
-			return envValue, true
+	if s.file.parseOptions.Interpolation || s.file.expandsEnv() {
+		var err error
+		value, ok, err = s.file.expand(s.name, key, s.file.parseOptions.Interpolation)
+		ok = ok && err == nil
+	} else {
+		value, ok = s.GetRaw(key)
+	}
+	if s.file.parseOptions.Dialect == DialectDesktop {
+		value = unescapeDesktop(value)
+	}
+	return
+}
+
+// Looks up a value for a key in a section without expanding references, along with a boolean result similar to a map lookup.
+// A key that is not in the section is looked up in the sections it inherits from.
+func (s *section) GetRaw(key string) (value string, ok bool) {
+	for _, sect := range s.lineage() {
+		if value, ok = sect.ownValue(key); ok {
+			return
 		}
 	}
-	value, ok = s.stringValues[key]
+	return
+}
+
+// Looks up a value for a key in this section alone
+func (s *section) ownValue(key string) (value string, ok bool) {
+	if s.overridden(key) {
+		return os.Getenv(s.envVarNameForKey(key)), true
+	}
+	value, ok = s.stringValues[s.file.norm(key)]
 	return
 }
 
@@ -63,6 +93,15 @@ func (s *section) GetBool(key string) (value bool, ok bool) {
 		return
 	}
 	ok = true
+	if !s.HasValue(key) {
+		// A key without a value is a flag that is set
+		value = true
+		return
+	}
+	if s.file.parseOptions.Dialect == DialectPHP {
+		value = phpBool(rawValue, s.quoted(key))
+		return
+	}
 	lowerCase := strings.ToLower(rawValue)
 	switch lowerCase {
 	case "", "0", "false", "no":
@@ -75,6 +114,28 @@ func (s *section) GetBool(key string) (value bool, ok bool) {
 	return
 }
 
+// Reports whether a key has a value, which may be empty. This is false for a key that is missing or was read without a value.
+func (s *section) HasValue(key string) bool {
+	for _, sect := range s.lineage() {
+		if sect.overridden(key) {
+			return true
+		}
+		if _, ok := sect.stringValues[s.file.norm(key)]; ok {
+			return !sect.noValue[s.file.norm(key)]
+		}
+	}
+	return false
+}
+
+// Whether an environment variable overrides a key in this section
+func (s *section) overridden(key string) bool {
+	if s.file == nil || !s.file.environmentOverrideEnabled {
+		return false
+	}
+	_, varIsSet := os.LookupEnv(s.envVarNameForKey(key))
+	return varIsSet
+}
+
 // Looks up a value for a key in this section and attempts to parse that value as an integer, along with a boolean result similar to a map lookup.
 // The `ok` boolean will be false in the event that the value could not be parsed as an int
 func (s *section) GetInt(key string) (value int, ok bool) {
@@ -92,7 +153,18 @@ func (s *section) GetInt(key string) (value int, ok bool) {
 }
 
 // Looks up a value for an array key in a section and returns that value, along with a boolean result similar to a map lookup.
+// A key that is not in the section is looked up in the sections it inherits from.
 func (s *section) GetArr(key string) (value []string, ok bool) {
+	for _, sect := range s.lineage() {
+		if value, ok = sect.ownArr(key); ok {
+			return
+		}
+	}
+	return
+}
+
+// Looks up a value for an array key in this section alone
+func (s *section) ownArr(key string) (value []string, ok bool) {
 	if s.file != nil && s.file.environmentOverrideEnabled {
 		baseEnvVarKey := s.envVarNameForKey(key)
 
@@ -123,17 +195,85 @@ func (s *section) GetArr(key string) (value []string, ok bool) {
 			return value, true
 		}
 	}
-	value, ok = s.arrayValues[key]
+	if s.file.parseOptions.Dialect == DialectDesktop {
+		var raw string
+		if raw, ok = s.stringValues[s.file.norm(key)]; ok {
+			value = desktopList(raw)
+		}
+		return
+	}
+	value, ok = s.arrayValues[s.file.norm(key)]
 	return
 }
 
+// Record the position of a key that is about to be stored, if it is new to the section
+func (s *section) track(key string) {
+	if _, found := s.stringValues[s.file.norm(key)]; found {
+		return
+	}
+	if _, found := s.arrayValues[s.file.norm(key)]; found {
+		return
+	}
+	if _, found := s.mapValues[s.file.norm(key)]; found {
+		return
+	}
+	s.keys = append(s.keys, key)
+}
+
 func (s *section) Set(key string, value string) (ok bool) {
-	s.stringValues[key] = value
+	if s.file.parseOptions.Dialect == DialectDesktop {
+		// Desktop entry values are stored as they are written
+		value = escapeDesktop(value)
+	}
+	if s.file.parseOptions.Dialect == DialectSystemd {
+		if value, ok = systemdValue(value); !ok {
+			return
+		}
+	}
+	return s.set(key, value)
+}
+
+func (s *section) set(key string, value string) (ok bool) {
+	s.track(key)
+	s.stringValues[s.file.norm(key)] = value
+	delete(s.noValue, s.file.norm(key))
+	if s.file.parseOptions.Dialect == DialectSystemd {
+		// The last value of a list is the one that is set
+		list := s.arrayValues[s.file.norm(key)]
+		if len(list) > 0 {
+			list = list[:len(list)-1]
+		}
+		s.arrayValues[s.file.norm(key)] = systemdList(list, value)
+	}
+	s.file.setLine(s.name, key, value)
 	return true
 }
 
 func (s *section) SetArr(key string, value []string) (ok bool) {
-	s.arrayValues[key] = value
+	if s.file.parseOptions.Dialect == DialectDesktop {
+		return s.set(key, joinDesktopList(value))
+	}
+	if s.file.parseOptions.Dialect == DialectSystemd {
+		escaped := make([]string, len(value))
+		for i, element := range value {
+			if escaped[i], ok = systemdValue(element); !ok {
+				return
+			}
+		}
+		value = escaped
+	}
+	s.track(key)
+	s.arrayValues[s.file.norm(key)] = value
+	s.reindex(key, value)
+	if s.file.parseOptions.Dialect == DialectSystemd {
+		// Get returns the last value of a list
+		last := ""
+		if len(value) > 0 {
+			last = value[len(value)-1]
+		}
+		s.stringValues[s.file.norm(key)] = last
+	}
+	s.file.setArrLines(s.name, key, value)
 	return true
 }
 
@@ -153,12 +293,23 @@ func (s *section) SetBool(key string, value bool) (ok bool) {
 }
 
 func (s *section) Remove(key string) {
-	_, found := s.stringValues[key]
+	id := s.file.norm(key)
+	_, found := s.stringValues[id]
 	if found {
-		delete(s.stringValues, key)
+		delete(s.stringValues, id)
 	}
-	_, found = s.arrayValues[key]
+	_, found = s.arrayValues[id]
 	if found {
-		delete(s.arrayValues, key)
+		delete(s.arrayValues, id)
+	}
+	delete(s.mapValues, id)
+	delete(s.indexed, id)
+	delete(s.noValue, id)
+	for i, name := range s.keys {
+		if s.file.norm(name) == id {
+			s.keys = append(s.keys[:i], s.keys[i+1:]...)
+			break
+		}
 	}
+	s.file.removeLines(s.name, key)
 }
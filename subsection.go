@@ -0,0 +1,52 @@
+package ini
+
+import (
+	"regexp"
+	"strings"
+)
+
+var subsectionRegex = regexp.MustCompile(`^([^\s"]+)\s+"((?:[^"\\]|\\.)*)"$`)
+
+// SubsectionName returns the name of a git-config style subsection, as used with the other methods of a File.
+// For example, SubsectionName("remote", "origin") is `remote "origin"`, which is written as [remote "origin"].
+func SubsectionName(section, subsection string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(subsection)
+	return section + ` "` + escaped + `"`
+}
+
+// SplitSubsection splits a section name into a section and a quoted subsection, as in [remote "origin"].
+// The `ok` boolean will be false if the name does not have a subsection.
+func SplitSubsection(name string) (section, subsection string, ok bool) {
+	groups := subsectionRegex.FindStringSubmatch(name)
+	if groups == nil {
+		return name, "", false
+	}
+	subsection = strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(groups[2])
+	return groups[1], subsection, true
+}
+
+// Returns the name that a section header refers to, which for a subsection does not depend on its spacing
+func canonicalSection(name string) string {
+	if section, subsection, ok := SplitSubsection(name); ok {
+		return SubsectionName(section, subsection)
+	}
+	return name
+}
+
+func (f *file) Subsections(section string) (value []string) {
+	value = []string{}
+	for _, name := range f.order {
+		if base, subsection, ok := SplitSubsection(name); ok && f.norm(base) == f.norm(section) {
+			value = append(value, subsection)
+		}
+	}
+	return
+}
+
+func (f *file) GetSub(section, subsection, key string) (value string, ok bool) {
+	return f.Get(SubsectionName(section, subsection), key)
+}
+
+func (f *file) SetSub(section, subsection, key, value string) (ok bool) {
+	return f.Set(SubsectionName(section, subsection), key, value)
+}
@@ -0,0 +1,47 @@
+package ini
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Add a value to a systemd list, where an empty value resets the list
+func systemdList(list []string, value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return append(list[:len(list):len(list)], value)
+}
+
+// The text to store and write for a systemd value. A backslash at the end would continue the line, so it is escaped
+// as \\, and ok is false for a value with a line break, which a unit file cannot hold.
+func systemdValue(value string) (text string, ok bool) {
+	if strings.ContainsAny(value, "\r\n") {
+		return "", false
+	}
+	if continues(value) {
+		value += `\`
+	}
+	return value, true
+}
+
+// LoadSystemdUnit creates a File from a systemd unit on disk and applies its drop-ins, the files ending in .conf
+// in a directory named after the unit with .d appended, in lexical order.
+// Values from drop-ins can be read, but only the unit itself is written out, along with any changes made to it.
+func LoadSystemdUnit(filename string) (File, error) {
+	unit, err := LoadFileWithOptions(filename, ParseOptions{Dialect: DialectSystemd})
+	if err != nil {
+		return unit, err
+	}
+	dropIns, err := filepath.Glob(filepath.Join(filename+".d", "*.conf"))
+	if err != nil {
+		return unit, err
+	}
+	for _, dropIn := range dropIns {
+		if err = unit.(*file).readDetached(dropIn); err != nil {
+			return unit, fmt.Errorf("systemd drop-in %s: %w", dropIn, err)
+		}
+	}
+	return unit, nil
+}
@@ -0,0 +1,94 @@
+package ini
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSystemd(t *testing.T) {
+	src := `[Unit]
+Description="My service"
+
+[Service]
+ExecStartPre=/bin/true
+ExecStart=/usr/bin/old
+ExecStart=
+ExecStart=/usr/bin/app \
+    --verbose
+Environment=A=1
+Environment=B=2
+`
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Dialect: DialectSystemd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, file, "Unit", "Description", `"My service"`)
+	checkArr(t, file, "Service", "ExecStart", []string{"/usr/bin/app --verbose"})
+	checkArr(t, file, "Service", "Environment", []string{"A=1", "B=2"})
+	checkStr(t, file, "Service", "Environment", "B=2")
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected an unchanged round trip; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	file.SetArr("Service", "Environment", []string{"A=1", "B=3", "C=4"})
+	file.SetArr("Service", "ExecStart", []string{"/usr/bin/new"})
+	file.Set("Service", "User", "app user")
+	expected := `[Unit]
+Description="My service"
+
+[Service]
+ExecStartPre=/bin/true
+ExecStart=/usr/bin/old
+ExecStart=
+ExecStart=/usr/bin/new
+Environment=A=1
+Environment=B=3
+Environment=C=4
+User=app user
+`
+	if out := writeString(t, file); out != expected {
+		t.Errorf("expected lists to be written as repeated keys; got: <<<%s<<< expected <<<%s<<<", out, expected)
+	}
+	checkStr(t, file, "Service", "Environment", "C=4")
+
+	if file.Set("Service", "Description", "two\nlines") || file.SetArr("Service", "Environment", []string{"A=1", "B=\r"}) {
+		t.Error("expected values with line breaks to be rejected")
+	}
+	file.Set("Service", "Path", `end\`)
+	file.Set("Service", "After", "z")
+	reread, err := LoadWithOptions(strings.NewReader(writeString(t, file)), ParseOptions{Dialect: DialectSystemd})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkStr(t, reread, "Service", "Path", `end\\`)
+	checkStr(t, reread, "Service", "After", "z")
+	checkArr(t, reread, "Service", "Environment", []string{"A=1", "B=3", "C=4"})
+}
+
+func TestSystemdDropIns(t *testing.T) {
+	dir := t.TempDir()
+	unit := filepath.Join(dir, "app.service")
+	src := "[Service]\nExecStart=/usr/bin/app\nRestart=no\nEnvironment=A=1\n"
+	writeFiles(t, dir, map[string]string{
+		"app.service":             src,
+		"app.service.d/20-b.conf": "[Service]\nRestart=always\nEnvironment=C=3\n",
+		"app.service.d/10-a.conf": "[Service]\nExecStart=\nExecStart=/usr/bin/app --debug\nEnvironment=B=2\n",
+		"app.service.d/notes.txt": "not a drop-in\n",
+	})
+	file, err := LoadSystemdUnit(unit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkArr(t, file, "Service", "ExecStart", []string{"/usr/bin/app --debug"})
+	checkArr(t, file, "Service", "Environment", []string{"A=1", "B=2", "C=3"})
+	checkStr(t, file, "Service", "Restart", "always")
+	if out := writeString(t, file); out != src {
+		t.Errorf("expected only the unit to be written; got: <<<%s<<< expected <<<%s<<<", out, src)
+	}
+
+	if _, err = LoadSystemdUnit(filepath.Join(dir, "missing.service")); !os.IsNotExist(err) {
+		t.Errorf("expected a missing unit to fail, got %v", err)
+	}
+}
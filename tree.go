@@ -0,0 +1,171 @@
+package ini
+
+import "strings"
+
+// A Tree is a hierarchical view over the sections of a file, for files that name sections as paths such as
+// [server.http.tls]. Sections that only exist as part of a path, such as server.http, are part of the tree too.
+// The default section is the root of the tree. The file itself is unchanged and keeps its flat section names.
+type Tree struct {
+	file Reader
+	sep  string
+	norm func(name string) string // The form that section names are compared in, see ParseOptions.Normalize
+}
+
+// NewTree returns a hierarchical view of a file, with section names split into paths at sep, which is "." if empty
+func NewTree(file Reader, sep string) *Tree {
+	if sep == "" {
+		sep = "."
+	}
+	return &Tree{file: file, sep: sep, norm: normalizerOf(file)}
+}
+
+// Returns how a file compares section names, which is exactly unless it is a file of this package with
+// ParseOptions.Normalize set
+func normalizerOf(r Reader) func(name string) string {
+	if f, ok := r.(*file); ok {
+		return f.norm
+	}
+	return func(name string) string { return name }
+}
+
+// Returns the form that a section name is compared in
+func (t *Tree) id(name string) string {
+	if t.norm == nil {
+		return name
+	}
+	return t.norm(name)
+}
+
+// Parent returns the section above a section in the tree, which is the default section for a top level section
+func (t *Tree) Parent(section string) string {
+	pos := strings.LastIndex(section, t.sep)
+	if pos < 0 || t.sep == "" {
+		return ""
+	}
+	return section[:pos]
+}
+
+// Every section in the tree, including those that only exist as part of a path, in the order they appear
+func (t *Tree) nodes() (nodes []string) {
+	seen := map[string]bool{"": true}
+	for _, section := range t.file.Sections() {
+		var path []string
+		for name := section; !seen[t.id(name)]; name = t.Parent(name) {
+			seen[t.id(name)] = true
+			path = append(path, name)
+		}
+		for i := len(path) - 1; i >= 0; i-- {
+			nodes = append(nodes, path[i])
+		}
+	}
+	return
+}
+
+// Children lists the full names of the sections directly below a section, in the order they appear in the file
+func (t *Tree) Children(section string) (value []string) {
+	value = []string{}
+	for _, name := range t.nodes() {
+		if t.id(t.Parent(name)) == t.id(section) {
+			value = append(value, name)
+		}
+	}
+	return
+}
+
+// Walk calls fn for a section and then for each section below it, depth first.
+// Walking stops at the first error returned by fn, and that error is returned.
+func (t *Tree) Walk(section string, fn func(section string) error) error {
+	if err := fn(section); err != nil {
+		return err
+	}
+	for _, child := range t.Children(section) {
+		if err := t.Walk(child, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Resolve looks up a key in a section, and then in each section above it in turn until the key is found.
+// Returns that value, along with a boolean result similar to a map lookup.
+func (t *Tree) Resolve(section, key string) (value string, ok bool) {
+	for {
+		if value, ok = t.file.Get(section, key); ok || section == "" {
+			return
+		}
+		section = t.Parent(section)
+	}
+}
+
+// SubTree returns a new File holding a section and the sections below it, named relative to that section.
+// The section itself becomes the default section of the new File.
+// The new File is read with the same ParseOptions as the file the tree is over, where it has any.
+func (t *Tree) SubTree(section string) File {
+	sub := NewFile()
+	if f, ok := t.file.(*file); ok {
+		sub = NewFileWithOptions(f.parseOptions)
+	}
+	t.file.Copy(subTreeSetter{dest: sub, root: section, sep: t.sep, norm: normalizerOf(t.file)})
+	return sub
+}
+
+// A subTreeSetter passes on values for the sections below a root, renaming them relative to it
+type subTreeSetter struct {
+	dest Setter
+	root string
+	sep  string
+	norm func(name string) string
+}
+
+// Returns the name of a section relative to the root, comparing the segments of the path in their normalized form
+func (s subTreeSetter) rename(section string) (name string, ok bool) {
+	if s.root == "" {
+		return section, true
+	}
+	rootPath, path := strings.Split(s.root, s.sep), strings.Split(section, s.sep)
+	if len(path) < len(rootPath) {
+		return "", false
+	}
+	for i, segment := range rootPath {
+		if s.norm(segment) != s.norm(path[i]) {
+			return "", false
+		}
+	}
+	return strings.Join(path[len(rootPath):], s.sep), true
+}
+
+func (s subTreeSetter) Set(section, key, value string) bool {
+	if name, ok := s.rename(section); ok {
+		return s.dest.Set(name, key, value)
+	}
+	return false
+}
+
+func (s subTreeSetter) SetInt(section, key string, value int) bool {
+	if name, ok := s.rename(section); ok {
+		return s.dest.SetInt(name, key, value)
+	}
+	return false
+}
+
+func (s subTreeSetter) SetBool(section, key string, value bool) bool {
+	if name, ok := s.rename(section); ok {
+		return s.dest.SetBool(name, key, value)
+	}
+	return false
+}
+
+func (s subTreeSetter) SetArr(section, key string, value []string) bool {
+	if name, ok := s.rename(section); ok {
+		return s.dest.SetArr(name, key, value)
+	}
+	return false
+}
+
+func (s subTreeSetter) SetMap(section, key string, value map[string]string) bool {
+	mapSetter, isMapSetter := s.dest.(MapSetter)
+	if name, ok := s.rename(section); ok && isMapSetter {
+		return mapSetter.SetMap(name, key, value)
+	}
+	return false
+}
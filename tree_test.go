@@ -0,0 +1,111 @@
+package ini
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTree(t *testing.T) {
+	src := `timeout = 30
+[server.http]
+port = 80
+[server.http.tls]
+port = 443
+cert = server.pem
+[server]
+host = example.com
+[client]
+retries = 3
+`
+	file, err := Load(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := NewTree(file, ".")
+
+	t.Run("children", func(t *testing.T) {
+		if children := tree.Children(""); !reflect.DeepEqual(children, []string{"server", "client"}) {
+			t.Errorf("Children(root): got %v", children)
+		}
+		if children := tree.Children("server.http"); !reflect.DeepEqual(children, []string{"server.http.tls"}) {
+			t.Errorf("Children(server.http): got %v", children)
+		}
+		if children := tree.Children("client"); len(children) != 0 {
+			t.Errorf("Children(client): expected none, got %v", children)
+		}
+		if parent := tree.Parent("server.http.tls"); parent != "server.http" {
+			t.Errorf("Parent(server.http.tls): got %q", parent)
+		}
+	})
+	t.Run("walk", func(t *testing.T) {
+		var visited []string
+		tree.Walk("", func(section string) error {
+			visited = append(visited, section)
+			return nil
+		})
+		if !reflect.DeepEqual(visited, []string{"", "server", "server.http", "server.http.tls", "client"}) {
+			t.Errorf("Walk: visited %v", visited)
+		}
+		stop := errors.New("stop")
+		visited = nil
+		err := tree.Walk("server", func(section string) error {
+			visited = append(visited, section)
+			if section == "server.http" {
+				return stop
+			}
+			return nil
+		})
+		if err != stop || len(visited) != 2 {
+			t.Errorf("Walk: expected to stop after 2 sections, got %v and %v", visited, err)
+		}
+	})
+	t.Run("resolve", func(t *testing.T) {
+		check := func(section, key, expect string, expectOk bool) {
+			value, ok := tree.Resolve(section, key)
+			if value != expect || ok != expectOk {
+				t.Errorf("Resolve(%q, %q): expected %q/%v, got %q/%v", section, key, expect, expectOk, value, ok)
+			}
+		}
+		check("server.http.tls", "port", "443", true)
+		check("server.http.tls", "host", "example.com", true)
+		check("server.http.tls", "timeout", "30", true)
+		check("server.http", "cert", "", false)
+		if value, ok := NewTree(file, "").Resolve("server.http.tls", "missing"); ok {
+			t.Errorf("Resolve with the default separator: expected a missing key, got %q", value)
+		}
+		if parent := (&Tree{file: file}).Parent("server.http"); parent != "" {
+			t.Errorf("Parent without a separator: got %q", parent)
+		}
+	})
+	t.Run("subTree", func(t *testing.T) {
+		sub := tree.SubTree("server")
+		if sections := sub.Sections(); !reflect.DeepEqual(sections, []string{"http", "http.tls", ""}) {
+			t.Errorf("SubTree sections: got %v", sections)
+		}
+		checkStr(t, sub, "", "host", "example.com")
+		checkStr(t, sub, "http.tls", "cert", "server.pem")
+		if _, ok := sub.Get("", "timeout"); ok {
+			t.Error("SubTree: expected no values from outside the tree")
+		}
+	})
+}
+
+func TestTreeNormalized(t *testing.T) {
+	src := "[Server.HTTP]\nport = 80\n[server.http.tls]\nport = 443\n"
+	file, err := LoadWithOptions(strings.NewReader(src), ParseOptions{Normalize: FoldCase})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := NewTree(file, ".")
+	if children := tree.Children(""); !reflect.DeepEqual(children, []string{"Server"}) {
+		t.Errorf("Children(root): got %v", children)
+	}
+	if children := tree.Children("Server.HTTP"); !reflect.DeepEqual(children, []string{"server.http.tls"}) {
+		t.Errorf("Children(Server.HTTP): got %v", children)
+	}
+	sub := tree.SubTree("server")
+	checkStr(t, sub, "http", "port", "80")
+	checkStr(t, sub, "HTTP.TLS", "port", "443")
+}
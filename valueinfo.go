@@ -0,0 +1,74 @@
+package ini
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ValueInfo describes how a value is written in a file, so that for example `key =`, `key = ""` and a key
+// without a value can be told apart.
+type ValueInfo struct {
+	Raw     string // The value as it is written, including any quotes and continuation lines
+	Quoted  bool   // The value is in quotes, including triple quotes
+	Quote   rune   // The quote character, or zero if the value is not quoted
+	NoValue bool   // The key has no value at all, see ParseOptions.AllowNoValue
+}
+
+// Empty reports whether nothing was written for the value, as in `key =`, which is not the same as `key = ""`
+func (info ValueInfo) Empty() bool {
+	return info.Raw == ""
+}
+
+// Returns the quote character around a value as it is written, or zero if it is not quoted.
+// This follows trimWithQuotes, so a double quoted value whose closing quote is escaped is not quoted.
+func quoteOf(raw string) rune {
+	if len(raw) < 2 {
+		return 0
+	}
+	switch {
+	case raw[0] == '"' && raw[len(raw)-1] == '"' && !continues(raw[1:len(raw)-1]):
+		return '"'
+	case raw[0] == '\'' && raw[len(raw)-1] == '\'':
+		return '\''
+	}
+	return 0
+}
+
+// Returns how a key in a section is written, along with a boolean result similar to a map lookup.
+// This describes the file, so values from environment variable overrides are not taken into account, and ok is false
+// for a value that is not written in the file itself, such as one read from an included file.
+func (f *file) GetInfo(section, key string) (info ValueInfo, ok bool) {
+	if _, found := f.lookup(section).stringValues[f.norm(key)]; !found {
+		return
+	}
+	lines := f.keyLines(section, key, valueLine)
+	if len(lines) == 0 {
+		return
+	}
+	ok = true
+	l := f.activeLine(lines)
+	info.Raw = l.value
+	info.Quote = quoteOf(l.value)
+	info.Quoted = info.Quote != 0
+	info.NoValue = l.bare && l.value == ""
+	return
+}
+
+// The text to write for a value replacing one that was written with the given quote character,
+// which keeps the quoting where the new value allows it
+func (f *file) formatQuoted(value string, quote rune) string {
+	if strings.Contains(value, "\n") || quote == 0 {
+		return f.formatValue(value)
+	}
+	if quote == '\'' {
+		// Single quotes have no escapes, so they can only hold a value without quotes or control characters
+		if !strings.Contains(value, "'") && strings.IndexFunc(value, unicode.IsControl) < 0 {
+			return "'" + value + "'"
+		}
+		return f.formatValue(value)
+	}
+	if f.parseOptions.Dialect == DialectPHP {
+		return phpQuote(value)
+	}
+	return quoteValue(value)
+}